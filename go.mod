@@ -6,11 +6,11 @@ toolchain go1.24.3
 
 require (
 	github.com/flynnkc/token-pool v1.0.0
+	github.com/gofrs/flock v0.10.0
 	github.com/oracle/oci-go-sdk/v65 v65.107.0
 )
 
 require (
-	github.com/gofrs/flock v0.10.0 // indirect
 	github.com/sony/gobreaker v0.5.0 // indirect
 	github.com/youmark/pkcs8 v0.0.0-20240726163527-a2c0da244d78 // indirect
 	golang.org/x/crypto v0.45.0 // indirect
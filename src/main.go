@@ -1,18 +1,25 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
 	"github.com/flynnkc/oci-frugal/src/pkg/configuration"
 	"github.com/flynnkc/oci-frugal/src/pkg/controller"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
 	"github.com/flynnkc/oci-frugal/src/pkg/id"
+	"github.com/flynnkc/oci-frugal/src/pkg/lock"
 	"github.com/flynnkc/oci-frugal/src/pkg/scheduler"
+	"github.com/flynnkc/oci-frugal/src/pkg/state"
 )
 
 const (
@@ -27,16 +34,27 @@ const (
 	KEYPASS      string = "KEY_PASS"
 	TAGNAMESPACE string = "TAG_NAMESPACE"
 	TIMEZONE     string = "TIMEZONE"
-)
-
-var (
-	services []string = []string{ // Supported services to be managed by the script
-		"instance",
-		"dbsystem",
-		"autonomousdatabase",
-		"analyticsinstance",
-		"integrationinstance",
-	}
+	WAIT         string = "WAIT_FOR_STATE"
+	WAITTIMEOUT  string = "WAIT_TIMEOUT"
+	STOPPOLICY   string = "STOP_POLICY"
+	STOPGRACE    string = "STOP_GRACE"
+	MYSQLSTOP    string = "MYSQL_SHUTDOWN"
+	EXADATA      string = "EXADATA_POLICY"
+	PROTECTIDS   string = "PROTECTED_IDS"
+	PROTECTCOMPS string = "PROTECTED_COMPARTMENTS"
+	PROTECTTAG   string = "PROTECT_TAG"
+	MAXSTOPS     string = "MAX_STOPS"
+	MAXSTOPPCT   string = "MAX_STOP_PERCENT"
+	BLASTACTION  string = "BLAST_ACTION"
+	SCHEDULES    string = "SCHEDULE_FILE"
+	INTERVAL     string = "INTERVAL"
+	STATEFILE    string = "STATE_FILE"
+	STATEBUCKET  string = "STATE_BUCKET"
+	LOCKFILE     string = "LOCK_FILE"
+	LOCKBUCKET   string = "LOCK_BUCKET"
+	LOCKTTL      string = "LOCK_TTL"
+	LISTEN       string = "LISTEN"
+	APITOKEN     string = "API_TOKEN"
 )
 
 func main() {
@@ -58,81 +76,643 @@ func main() {
 		"Principal", *cfg.AuthType(),
 		"Scheduler", configuration.ANYKEYNL_SCHEDULER,
 		"Action", *cfg.Action(),
-		"Timezone", cfg.Timezone())
+		"Timezone", cfg.Timezone(),
+		"Wait For State", cfg.WaitForState(),
+		"Stop Policy", cfg.StopPolicy())
+
+	// Subcommands follow flags [ex. frugal -region us-ashburn-1 plan plan.json]
+	switch flag.Arg(0) {
+	case "plan":
+		plan(cfg, flag.Arg(1))
+	case "serve":
+		serve(cfg)
+	case "apply":
+		if ctx, release, ok := acquireLock(cfg, log); ok {
+			defer release()
+			apply(ctx, cfg, flag.Arg(1))
+		}
+	case "start", "stop":
+		if ctx, release, ok := acquireLock(cfg, log); ok {
+			defer release()
+			direct(ctx, cfg, flag.Arg(0), flag.Args()[1:])
+		}
+	case "":
+		if ctx, release, ok := acquireLock(cfg, log); ok {
+			defer release()
+			run(ctx, cfg)
+		}
+	default:
+		log.Error("Unknown command", "Command", flag.Arg(0))
+		os.Exit(1)
+	}
+}
+
+// newLock returns the configured run lock or nil if instances do not coordinate
+func newLock(cfg *configuration.Configuration) (lock.Lock, error) {
+	switch {
+	case cfg.LockBucket() != "":
+		return lock.NewObjectLock(cfg.Provider(), cfg.LockBucket(), cfg.LockTTL(),
+			cfg.MakeLog("Component", "Lock"))
+	case cfg.LockFile() != "":
+		return lock.NewFileLock(cfg.LockFile()), nil
+	default:
+		return nil, nil
+	}
+}
+
+// acquireLock takes the run lock if one is configured, returning a context
+// cancelled if the lock is lost and a function releasing it. Returns false if
+// another instance holds the lock. The lock is also released if the process is
+// interrupted or terminated.
+func acquireLock(cfg *configuration.Configuration,
+	log *slog.Logger) (context.Context, func(), bool) {
+	l, err := newLock(cfg)
+	if err != nil {
+		log.Error("Unable to create run lock", "error", err)
+		os.Exit(1)
+	} else if l == nil {
+		return context.Background(), func() {}, true
+	}
+
+	ok, err := l.TryLock()
+	if err != nil {
+		log.Error("Unable to acquire run lock", "error", err)
+		os.Exit(1)
+	} else if !ok {
+		log.Info("Run lock held by another instance, exiting")
+		return nil, nil, false
+	}
+	log.Debug("Run lock acquired")
+
+	ctx, stop := lockContext(l, log)
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			stop()
+			if err := l.Unlock(); err != nil {
+				log.Error("Unable to release run lock", "error", err)
+			}
+		})
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.Warn("Shutting down", "Signal", sig.String())
+		release()
+		os.Exit(1)
+	}()
 
-	run(cfg)
+	return ctx, release, true
 }
 
-func run(cfg *configuration.Configuration) {
+// lockContext returns a context cancelled with lock.ErrLost if the held lock l is
+// lost, and a function to call once the run is finished
+func lockContext(l lock.Lock, log *slog.Logger) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	go func() {
+		select {
+		case <-l.Lost():
+			log.Error("Run lock lost, stopping run")
+			cancel(lock.ErrLost)
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() { cancel(nil) }
+}
+
+// run plans and executes actions in every region
+func run(ctx context.Context, cfg *configuration.Configuration) {
 	startTime := time.Now()
 	log := cfg.MakeLog("Component", "Main")
 
-	log.Info("Supported Services", "Services", strings.Join(services, ", "))
+	defaults := compartmentDefaults(cfg, log)
+	execute(ctx, cfg, getRegions(cfg, log), defaults, "", log)
 
-	// Set region based on flag/environment variable
-	var regions []string
-	if *cfg.Region() != "" {
-		regions = append(regions, *cfg.Region())
-		log.Debug("Region specified in flags, not retrieving subscribed regions",
-			"Region", regions[0])
-	} else {
-		// Get list of subscribed regions
-		idClient, err := id.NewIdentityClient(cfg.Provider())
+	log.Info("Finished tasks",
+		"duration", time.Since(startTime))
+}
+
+// execute plans and executes actions in regions, limited to a compartment and
+// beneath it unless empty, returning the summary of each region run. Tasks are no
+// longer handled once ctx is done.
+func execute(ctx context.Context, cfg *configuration.Configuration, regions []string,
+	defaults *controller.CompartmentDefaults, compartment string,
+	log *slog.Logger) []*controller.RunSummary {
+	store := newStateStore(cfg, log)
+	now := time.Now()
+
+	// Main control loop
+	lc := len(regions)
+	runs := make([]regionRun, 0, lc)
+	var wg sync.WaitGroup
+	for i, region := range regions {
+		log.Info("BEGIN SCALING IN REGION",
+			"Region", region,
+			"Order", i,
+			"Region Count", lc)
+
+		controller, err := newController(cfg, region, defaults)
 		if err != nil {
-			log.Error("error getting identity client",
+			log.Error("Unable to create controller",
+				"Region", region,
 				"error", err)
-			os.Exit(1)
+			continue
+		}
+		// Services searched for are the same in every region
+		if len(runs) == 0 {
+			log.Info("Supported Services",
+				"Services", strings.Join(controller.SearchTypes(), ", "))
+		}
+		last := lastRun(store, region, log)
+		since, retry := period(cfg, last, region, now, log)
+		controller.SetPeriod(since, now)
+		controller.SetRetry(retry)
+		controller.SetCompartment(compartment)
+		controller.SetContext(ctx)
+		runs = append(runs, regionRun{controller: controller, compartment: compartment,
+			last: last, since: since})
+
+		wg.Add(1)
+		go controller.Run(&wg)
+	}
+	wg.Wait()
+
+	recordRuns(store, runs, now, log)
+
+	summaries := make([]*controller.RunSummary, 0, len(runs))
+	for _, r := range runs {
+		if s := r.controller.Summary(); s != nil {
+			summaries = append(summaries, s)
 		}
+	}
+
+	return summaries
+}
+
+// plan writes the actions planned in every region to file without taking any
+// action
+func plan(cfg *configuration.Configuration, file string) {
+	log := cfg.MakeLog("Component", "Main")
 
-		regions, err = idClient.GetRegions()
+	// Logs are written to stdout so the plan requires its own file
+	if file == "" {
+		log.Error("plan requires a plan file [ex. frugal plan plan.json]")
+		os.Exit(1)
+	}
+
+	defaults := compartmentDefaults(cfg, log)
+	plans := planRegions(cfg, getRegions(cfg, log), defaults, "", log)
+
+	f, err := os.Create(file)
+	if err != nil {
+		log.Error("Unable to create plan file",
+			"File", file,
+			"error", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	if err := controller.WritePlans(f, plans); err != nil {
+		log.Error("Unable to write plan",
+			"error", err)
+		os.Exit(1)
+	}
+
+	log.Info("Plan written",
+		"File", file,
+		"Regions", len(plans))
+}
+
+// planRegions returns the actions planned in regions, limited to a compartment
+// and beneath it unless empty, without taking any action
+func planRegions(cfg *configuration.Configuration, regions []string,
+	defaults *controller.CompartmentDefaults, compartment string,
+	log *slog.Logger) []*controller.Plan {
+	store := newStateStore(cfg, log)
+	now := time.Now()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	plans := make([]*controller.Plan, 0)
+	for _, region := range regions {
+		tc, err := newController(cfg, region, defaults)
 		if err != nil {
-			log.Error("error getting regions",
+			log.Error("Unable to create controller",
+				"Region", region,
 				"error", err)
+			continue
 		}
-		if len(regions) == 0 {
-			log.Error("error no regions set")
-			os.Exit(1)
-		}
+		since, retry := period(cfg, lastRun(store, region, log), region, now, log)
+		tc.SetPeriod(since, now)
+		tc.SetRetry(retry)
+		tc.SetCompartment(compartment)
 
-		log.Debug("Subscribed regions",
-			"Regions", regions)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := tc.Plan()
+			if err != nil {
+				log.Error("Unable to plan region",
+					"Region", region,
+					"error", err)
+				return
+			}
+
+			mu.Lock()
+			plans = append(plans, p)
+			mu.Unlock()
+		}()
 	}
+	wg.Wait()
 
-	schFunc := scheduler.ScheduleFunc(*cfg.ScheduleType())
-	sch := schFunc()
+	return plans
+}
+
+// apply executes a plan written by the plan command
+func apply(ctx context.Context, cfg *configuration.Configuration, file string) {
+	startTime := time.Now()
+	log := cfg.MakeLog("Component", "Main")
+
+	if file == "" {
+		log.Error("apply requires a plan file [ex. frugal apply plan.json]")
+		os.Exit(1)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		log.Error("Unable to open plan file",
+			"File", file,
+			"error", err)
+		os.Exit(1)
+	}
+	plans, err := controller.ReadPlans(f)
+	f.Close()
+	if err != nil {
+		log.Error("Unable to read plan",
+			"File", file,
+			"error", err)
+		os.Exit(1)
+	}
+
+	// Protection is checked again with the current compartment hierarchy
+	defaults := compartmentDefaults(cfg, log)
 
-	// Main control loop
-	lc := len(regions)
 	var wg sync.WaitGroup
-	for i, region := range regions {
-		log.Info("BEGIN SCALING IN REGION",
-			"Region", region,
-			"Order", i,
-			"Region Count", lc)
+	for _, p := range plans {
+		log.Info("APPLYING PLAN IN REGION",
+			"Region", p.Region,
+			"Created", p.Created,
+			"Tasks", len(p.Tasks))
+
+		tc, err := newController(cfg, p.Region, defaults)
+		if err != nil {
+			log.Error("Unable to create controller",
+				"Region", p.Region,
+				"error", err)
+			continue
+		}
+		tc.SetContext(ctx)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tc.Execute(p)
+		}()
+	}
+	wg.Wait()
+
+	log.Info("Finished tasks",
+		"duration", time.Since(startTime))
+}
+
+// direct starts or stops resources by OCID or compartment now, bypassing
+// schedules [ex. frugal stop --compartment dev-team ocid1.instance.oc1..aaaa]
+func direct(ctx context.Context, cfg *configuration.Configuration, command string,
+	args []string) {
+	startTime := time.Now()
+	log := cfg.MakeLog("Component", "Main")
+
+	act := action.ON
+	if command == "stop" {
+		act = action.OFF
+	}
+
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	compartment := fs.String("compartment", "",
+		"compartment name or OCID whose resources, and those beneath it, are acted on")
+	fs.Parse(args)
+	ids := fs.Args()
 
-		controllerOpts := controller.ControllerOpts{
-			ConfigurationProvider: cfg.Provider(),
-			TagNamespace:          cfg.TagNamespace(),
-			Scheduler:             sch,
-			SupportedActions:      *cfg.Action(),
-			LogFunc:               cfg.MakeLog,
+	if *compartment == "" && len(ids) == 0 {
+		log.Error(command + " requires a compartment or resource OCIDs " +
+			"[ex. frugal " + command + " --compartment dev-team]")
+		os.Exit(1)
+	}
+
+	defaults := compartmentDefaults(cfg, log)
+	scope := ""
+	if *compartment != "" {
+		var err error
+		if scope, err = defaults.Lookup(*compartment); err != nil {
+			log.Error("Unable to find compartment", "error", err)
+			os.Exit(1)
 		}
+	}
 
-		controller, err := controller.NewTagController(controllerOpts)
+	var found sync.Map
+	var wg sync.WaitGroup
+	for _, region := range getRegions(cfg, log) {
+		tc, err := newController(cfg, region, defaults)
 		if err != nil {
 			log.Error("Unable to create controller",
+				"Region", region,
 				"error", err)
+			continue
 		}
-		controller.SetRegion(region)
-		go controller.Run(&wg)
+		tc.SetCompartment(scope)
+		tc.SetContext(ctx)
+
 		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := tc.Direct(act, ids)
+			if err != nil {
+				log.Error("Unable to plan region",
+					"Region", region,
+					"error", err)
+				return
+			}
+
+			for _, tasks := range [][]task.Task{p.Tasks, p.Blocked} {
+				for _, t := range tasks {
+					found.Store(*t.Resource.Identifier, true)
+				}
+			}
+			tc.Execute(p)
+		}()
 	}
 	wg.Wait()
 
+	for _, id := range ids {
+		if _, ok := found.Load(id); !ok {
+			log.Warn("Resource not found or not supported", "Identifier", id)
+		}
+	}
+
 	log.Info("Finished tasks",
 		"duration", time.Since(startTime))
 }
 
+// getRegions returns the configured region or every subscribed region
+func getRegions(cfg *configuration.Configuration, log *slog.Logger) []string {
+	// Set region based on flag/environment variable
+	var regions []string
+	if *cfg.Region() != "" {
+		regions = append(regions, *cfg.Region())
+		log.Debug("Region specified in flags, not retrieving subscribed regions",
+			"Region", regions[0])
+		return regions
+	}
+
+	// Get list of subscribed regions
+	regions, err := subscribedRegions(cfg)
+	if err != nil {
+		log.Error("error getting regions",
+			"error", err)
+	}
+	if len(regions) == 0 {
+		log.Error("error no regions set")
+		os.Exit(1)
+	}
+
+	log.Debug("Subscribed regions",
+		"Regions", regions)
+
+	return regions
+}
+
+// subscribedRegions returns every region the tenancy is subscribed to
+func subscribedRegions(cfg *configuration.Configuration) ([]string, error) {
+	idClient, err := id.NewIdentityClient(cfg.Provider())
+	if err != nil {
+		return nil, fmt.Errorf("error getting identity client: %w", err)
+	}
+
+	return idClient.GetRegions()
+}
+
+// newScheduler creates a scheduler with the groups and templates of the schedule
+// file. Each controller has its own scheduler as resources may define groups per
+// region.
+func newScheduler(cfg *configuration.Configuration) scheduler.Scheduler {
+	schFunc := scheduler.ScheduleFunc(*cfg.ScheduleType())
+	sch := schFunc()
+
+	if g, ok := sch.(scheduler.Grouper); ok {
+		for name, keys := range cfg.Schedules().Groups {
+			g.SetGroup(name, keys)
+		}
+	}
+
+	if t, ok := sch.(scheduler.Templater); ok {
+		for name, schedule := range cfg.Schedules().Templates {
+			t.SetTemplate(name, schedule)
+		}
+	}
+
+	return sch
+}
+
+// compartmentDefaults reads default schedules from compartment tags and the
+// schedule file. If compartments cannot be listed only schedule file defaults
+// for a resource's own compartment apply.
+func compartmentDefaults(cfg *configuration.Configuration,
+	log *slog.Logger) *controller.CompartmentDefaults {
+	var compartments []id.Compartment
+
+	idClient, err := id.NewIdentityClient(cfg.Provider())
+	if err == nil {
+		compartments, err = idClient.GetCompartments()
+	}
+	if err != nil && len(cfg.ProtectedCompartments()) > 0 {
+		log.Error("Unable to read compartments, OFF actions blocked as protected compartments cannot be checked",
+			"error", err)
+	} else if err != nil {
+		log.Warn("Unable to read compartments, compartment tag defaults disabled",
+			"error", err)
+	}
+
+	log.Debug("Compartment defaults loaded",
+		"Compartments", len(compartments),
+		"Schedule File Defaults", len(cfg.Schedules().Compartments))
+
+	return controller.NewCompartmentDefaults(compartments, *cfg.TagNamespace(),
+		cfg.Schedules().Compartments)
+}
+
+// lastRun returns the last run in region, a zero Run if there is none or last
+// runs are not persisted
+func lastRun(store state.Store, region string, log *slog.Logger) state.Run {
+	if store == nil {
+		return state.Run{}
+	}
+
+	last, err := store.LastRun(region)
+	if err != nil {
+		log.Warn("Unable to read last run",
+			"Region", region,
+			"error", err)
+	}
+
+	return last
+}
+
+// period returns the start of the period ending at now that schedule transitions
+// are evaluated over in region, or the zero time to evaluate the current hour,
+// and the earlier start of resources the last run did not finish. The last run is
+// preferred so missed runs are replayed.
+func period(cfg *configuration.Configuration, last state.Run, region string,
+	now time.Time, log *slog.Logger) (time.Time, map[string]time.Time) {
+	limit := now.Add(-state.MAX_REPLAY)
+
+	retry := make(map[string]time.Time, len(last.Retry))
+	for id, t := range last.Retry {
+		if !t.IsZero() && t.Before(limit) {
+			t = limit
+		}
+		retry[id] = t
+	}
+
+	switch {
+	case !last.Time.IsZero() && last.Time.Before(limit):
+		log.Warn("Last run is older than the replay limit",
+			"Region", region,
+			"Last Run", last.Time,
+			"Replay Limit", state.MAX_REPLAY)
+		return limit, retry
+	case !last.Time.IsZero():
+		return last.Time, retry
+	case cfg.Interval() == 0:
+		// First run, evaluate the current hour
+		return time.Time{}, retry
+	default:
+		return now.Add(-cfg.Interval()), retry
+	}
+}
+
+// newStateStore returns the configured store of last run times or nil if last
+// runs are not persisted
+func newStateStore(cfg *configuration.Configuration, log *slog.Logger) state.Store {
+	switch {
+	case cfg.StateBucket() != "":
+		s, err := state.NewObjectStore(cfg.Provider(), cfg.StateBucket())
+		if err != nil {
+			log.Error("Unable to use state bucket, missed runs will not be replayed",
+				"Bucket", cfg.StateBucket(),
+				"error", err)
+			return nil
+		}
+		return s
+	case cfg.StateFile() != "":
+		return state.NewFileStore(cfg.StateFile())
+	default:
+		return nil
+	}
+}
+
+// regionRun is a controller run in a region with the state it started from
+type regionRun struct {
+	controller  runController
+	compartment string // Compartment the run was limited to, empty for all
+	last        state.Run
+	since       time.Time
+}
+
+// runController is the part of a controller recordRuns reads once it has run
+type runController interface {
+	Region() string
+	Summary() *controller.RunSummary
+}
+
+// recordRuns stores now as the last run of every region that was evaluated.
+// Resources a run did not finish are kept to be replayed from their earlier
+// start. Runs limited to a compartment do not cover every transition in their
+// region and are not recorded.
+func recordRuns(store state.Store, runs []regionRun, now time.Time, log *slog.Logger) {
+	if store == nil {
+		return
+	}
+
+	for _, r := range runs {
+		if r.compartment != "" {
+			log.Debug("Not recording run limited to a compartment",
+				"Region", r.controller.Region(),
+				"Compartment", r.compartment)
+			continue
+		}
+
+		region := r.controller.Region()
+		s := r.controller.Summary()
+		if s == nil {
+			log.Warn("Region not evaluated, transitions will be replayed next run",
+				"Region", region)
+			continue
+		}
+
+		unfinished := s.Unfinished()
+		if len(unfinished) > 0 {
+			log.Warn("Resources unfinished, their transitions will be replayed next run",
+				"Region", region,
+				"Resources", unfinished)
+		}
+
+		next := state.Next(r.last, r.since, now, unfinished)
+		if err := store.SetLastRun(region, next); err != nil {
+			log.Error("Unable to record last run",
+				"Region", region,
+				"error", err)
+		}
+	}
+}
+
+// newController creates a controller for region from the configuration
+func newController(cfg *configuration.Configuration, region string,
+	defaults *controller.CompartmentDefaults) (*controller.TagController, error) {
+	controllerOpts := controller.ControllerOpts{
+		ConfigurationProvider: cfg.Provider(),
+		TagNamespace:          cfg.TagNamespace(),
+		Scheduler:             newScheduler(cfg),
+		SupportedActions:      *cfg.Action(),
+		LogFunc:               cfg.MakeLog,
+		WaitForState:          cfg.WaitForState(),
+		WaitTimeout:           cfg.WaitTimeout(),
+		StopPolicy:            cfg.StopPolicy(),
+		StopGrace:             cfg.StopGrace(),
+		MysqlShutdown:         cfg.MysqlShutdown(),
+		ExadataPolicy:         cfg.ExadataPolicy(),
+		ProtectedIDs:          cfg.ProtectedIDs(),
+		ProtectedCompartments: cfg.ProtectedCompartments(),
+		ProtectTag:            cfg.ProtectTag(),
+		CompartmentDefaults:   defaults,
+		BlastRadius: controller.BlastRadius{
+			MaxStops:       cfg.MaxStops(),
+			MaxStopPercent: cfg.MaxStopPercent(),
+			DryRun:         cfg.BlastAction() == configuration.BLAST_DRY_RUN,
+		},
+	}
+
+	c, err := controller.NewTagController(controllerOpts)
+	if err != nil {
+		return nil, err
+	}
+	c.SetRegion(region)
+
+	return c, nil
+}
+
 func setup() configuration.ConfigurationOpts {
 	c := configuration.ConfigurationOpts{}
 	// Add flag variables as first priority
@@ -207,6 +787,153 @@ func addFlags(c configuration.ConfigurationOpts) configuration.ConfigurationOpts
 		return nil
 	})
 
+	// Wait for state
+	flag.Func("wait", "wait for resources to reach target state [true, false]",
+		func(s string) error {
+			opts.WaitForState = &s
+			return nil
+		})
+
+	// Wait timeout
+	flag.Func("wait-timeout", "time to wait for each resource to reach target state [ex. 10m]",
+		func(s string) error {
+			opts.WaitTimeout = &s
+			return nil
+		})
+
+	// Compute stop policy
+	stopHelp := fmt.Sprintf("default compute stop policy [%s, %s, %s]",
+		configuration.STOP_HARD,
+		configuration.STOP_SOFT,
+		configuration.STOP_GRACEFUL)
+	flag.Func("stop-policy", stopHelp, func(s string) error {
+		opts.StopPolicy = &s
+		return nil
+	})
+
+	// Graceful stop grace period
+	flag.Func("stop-grace", "time before a graceful stop powers off an instance [ex. 5m]",
+		func(s string) error {
+			opts.StopGrace = &s
+			return nil
+		})
+
+	// MySQL shutdown type
+	mysqlHelp := fmt.Sprintf("shutdown type for MySQL DB systems [%s, %s, %s]",
+		configuration.MYSQL_SHUTDOWN_FAST,
+		configuration.MYSQL_SHUTDOWN_SLOW,
+		configuration.MYSQL_SHUTDOWN_IMMEDIATE)
+	flag.Func("mysql-shutdown", mysqlHelp, func(s string) error {
+		opts.MysqlShutdown = &s
+		return nil
+	})
+
+	// Exadata policy
+	exadataHelp := fmt.Sprintf("handling of Exadata DB systems and VM clusters [%s, %s]",
+		configuration.EXADATA_SKIP,
+		configuration.EXADATA_VM_CLUSTER)
+	flag.Func("exadata", exadataHelp, func(s string) error {
+		opts.ExadataPolicy = &s
+		return nil
+	})
+
+	// Protected resources
+	flag.Func("protect-ids", "comma separated OCIDs of resources never turned off",
+		func(s string) error {
+			opts.ProtectedIDs = &s
+			return nil
+		})
+
+	flag.Func("protect-compartments",
+		"comma separated compartment OCIDs whose resources, including in nested compartments, are never turned off",
+		func(s string) error {
+			opts.ProtectedCompartments = &s
+			return nil
+		})
+
+	protectHelp := fmt.Sprintf("freeform tag protecting resources from being turned off, empty to disable [default %s]",
+		configuration.DEFAULT_PROTECT_TAG)
+	flag.Func("protect-tag", protectHelp, func(s string) error {
+		opts.ProtectTag = &s
+		return nil
+	})
+
+	// Blast radius
+	flag.Func("max-stops", "maximum stop actions per region per run, 0 for unlimited",
+		func(s string) error {
+			opts.MaxStops = &s
+			return nil
+		})
+
+	flag.Func("max-stop-percent",
+		"maximum percent of managed resources stopped per region per run, 0 for unlimited",
+		func(s string) error {
+			opts.MaxStopPercent = &s
+			return nil
+		})
+
+	blastHelp := fmt.Sprintf("action when a run exceeds the stop limits [%s, %s]",
+		configuration.BLAST_ABORT,
+		configuration.BLAST_DRY_RUN)
+	flag.Func("blast-action", blastHelp, func(s string) error {
+		opts.BlastAction = &s
+		return nil
+	})
+
+	// Schedule file
+	flag.Func("schedules", "JSON file of shared schedule definitions", func(s string) error {
+		opts.ScheduleFile = &s
+		return nil
+	})
+
+	// Run interval
+	flag.Func("interval", "time between runs, act on schedule transitions within it [ex. 15m]",
+		func(s string) error {
+			opts.Interval = &s
+			return nil
+		})
+
+	// Last run state
+	flag.Func("state-file", "file recording the last run to replay missed transitions",
+		func(s string) error {
+			opts.StateFile = &s
+			return nil
+		})
+	flag.Func("state-bucket", "Object Storage bucket recording the last run to replay missed transitions",
+		func(s string) error {
+			opts.StateBucket = &s
+			return nil
+		})
+
+	// Run lock
+	flag.Func("lock-file", "file locked while running so only one instance acts",
+		func(s string) error {
+			opts.LockFile = &s
+			return nil
+		})
+	flag.Func("lock-bucket", "Object Storage bucket holding the run lock so only one instance acts",
+		func(s string) error {
+			opts.LockBucket = &s
+			return nil
+		})
+	flag.Func("lock-ttl", "time before the run lock of a crashed instance expires [ex. 15m]",
+		func(s string) error {
+			opts.LockTTL = &s
+			return nil
+		})
+
+	// HTTP API
+	flag.Func("listen", "address the HTTP API listens on with the serve command [ex. 127.0.0.1:8080]",
+		func(s string) error {
+			opts.Listen = &s
+			return nil
+		})
+	flag.Func("api-token", "bearer token required by the HTTP API, prefer the environment variable",
+		func(s string) error {
+			opts.APIToken = &s
+			return nil
+		})
+
 	flag.Parse()
 
 	return opts
@@ -251,6 +978,90 @@ func addEnvironment(c configuration.ConfigurationOpts) configuration.Configurati
 		opts.TagNamespace = checkEnv(PREFIX + TAGNAMESPACE)
 	}
 
+	if opts.WaitForState == nil {
+		opts.WaitForState = checkEnv(PREFIX + WAIT)
+	}
+
+	if opts.WaitTimeout == nil {
+		opts.WaitTimeout = checkEnv(PREFIX + WAITTIMEOUT)
+	}
+
+	if opts.StopPolicy == nil {
+		opts.StopPolicy = checkEnv(PREFIX + STOPPOLICY)
+	}
+
+	if opts.StopGrace == nil {
+		opts.StopGrace = checkEnv(PREFIX + STOPGRACE)
+	}
+
+	if opts.MysqlShutdown == nil {
+		opts.MysqlShutdown = checkEnv(PREFIX + MYSQLSTOP)
+	}
+
+	if opts.ExadataPolicy == nil {
+		opts.ExadataPolicy = checkEnv(PREFIX + EXADATA)
+	}
+
+	if opts.ProtectedIDs == nil {
+		opts.ProtectedIDs = checkEnv(PREFIX + PROTECTIDS)
+	}
+
+	if opts.ProtectedCompartments == nil {
+		opts.ProtectedCompartments = checkEnv(PREFIX + PROTECTCOMPS)
+	}
+
+	if opts.ProtectTag == nil {
+		opts.ProtectTag = checkEnv(PREFIX + PROTECTTAG)
+	}
+
+	if opts.MaxStops == nil {
+		opts.MaxStops = checkEnv(PREFIX + MAXSTOPS)
+	}
+
+	if opts.MaxStopPercent == nil {
+		opts.MaxStopPercent = checkEnv(PREFIX + MAXSTOPPCT)
+	}
+
+	if opts.BlastAction == nil {
+		opts.BlastAction = checkEnv(PREFIX + BLASTACTION)
+	}
+
+	if opts.ScheduleFile == nil {
+		opts.ScheduleFile = checkEnv(PREFIX + SCHEDULES)
+	}
+
+	if opts.Interval == nil {
+		opts.Interval = checkEnv(PREFIX + INTERVAL)
+	}
+
+	if opts.StateFile == nil {
+		opts.StateFile = checkEnv(PREFIX + STATEFILE)
+	}
+
+	if opts.StateBucket == nil {
+		opts.StateBucket = checkEnv(PREFIX + STATEBUCKET)
+	}
+
+	if opts.LockFile == nil {
+		opts.LockFile = checkEnv(PREFIX + LOCKFILE)
+	}
+
+	if opts.LockBucket == nil {
+		opts.LockBucket = checkEnv(PREFIX + LOCKBUCKET)
+	}
+
+	if opts.LockTTL == nil {
+		opts.LockTTL = checkEnv(PREFIX + LOCKTTL)
+	}
+
+	if opts.Listen == nil {
+		opts.Listen = checkEnv(PREFIX + LISTEN)
+	}
+
+	if opts.APIToken == nil {
+		opts.APIToken = checkEnv(PREFIX + APITOKEN)
+	}
+
 	return opts
 }
 
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/controller"
+	"github.com/flynnkc/oci-frugal/src/pkg/state"
+)
+
+type testStore map[string]state.Run
+
+func (s testStore) LastRun(key string) (state.Run, error) {
+	return s[key], nil
+}
+
+func (s testStore) SetLastRun(key string, r state.Run) error {
+	s[key] = r
+	return nil
+}
+
+type testController struct {
+	region  string
+	summary *controller.RunSummary
+}
+
+func (c testController) Region() string                  { return c.region }
+func (c testController) Summary() *controller.RunSummary { return c.summary }
+
+func TestRecordRuns_Scoped(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-time.Hour)
+	store := testStore{}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	runs := []regionRun{
+		{controller: testController{"us-ashburn-1", &controller.RunSummary{}}, since: since},
+		{controller: testController{"us-phoenix-1", &controller.RunSummary{}},
+			compartment: "ocid1.compartment.oc1..dev", since: since},
+		{controller: testController{"eu-frankfurt-1", nil}, since: since},
+	}
+	recordRuns(store, runs, now, log)
+
+	if r, ok := store["us-ashburn-1"]; !ok || !r.Time.Equal(now) {
+		t.Fatalf("expected unscoped run recorded at %v, got %+v", now, r)
+	}
+	if _, ok := store["us-phoenix-1"]; ok {
+		t.Fatalf("expected run limited to a compartment not to be recorded")
+	}
+	if _, ok := store["eu-frankfurt-1"]; ok {
+		t.Fatalf("expected region not evaluated not to be recorded")
+	}
+}
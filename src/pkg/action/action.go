@@ -1,5 +1,11 @@
 package action
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 const (
 	NULL_ACTION Action = 0      // 00000000
 	OFF         Action = 1 << 0 // 00000001
@@ -18,3 +24,46 @@ func ToAction(i int) Action {
 func Compare(a Action, b Action) bool {
 	return (a & b) > 0
 }
+
+// String returns the name of the action or its number if unnamed
+func (a Action) String() string {
+	switch a {
+	case NULL_ACTION:
+		return "none"
+	case OFF:
+		return "off"
+	case ON:
+		return "on"
+	case ALL:
+		return "all"
+	default:
+		return strconv.Itoa(int(a))
+	}
+}
+
+// MarshalText encodes the action by name so plans and summaries are readable
+func (a Action) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText decodes an action from its name or number
+func (a *Action) UnmarshalText(b []byte) error {
+	switch s := strings.ToLower(string(b)); s {
+	case "none", "":
+		*a = NULL_ACTION
+	case "off":
+		*a = OFF
+	case "on":
+		*a = ON
+	case "all":
+		*a = ALL
+	default:
+		i, err := strconv.ParseUint(s, 10, 8)
+		if err != nil {
+			return fmt.Errorf("invalid action %s", b)
+		}
+		*a = Action(i)
+	}
+
+	return nil
+}
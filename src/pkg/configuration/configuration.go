@@ -3,6 +3,7 @@ package configuration
 import (
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
 	"time"
 
@@ -24,6 +25,42 @@ const (
 	// Scheduler
 	NULL_SCHEDULER     string = "nullscheduler"
 	ANYKEYNL_SCHEDULER string = "anykeynl"
+
+	// Compute stop policies
+	STOP_HARD     string = "hard"     // Immediate power off
+	STOP_SOFT     string = "soft"     // ACPI shutdown only
+	STOP_GRACEFUL string = "graceful" // ACPI shutdown, power off after grace period
+
+	DEFAULT_STOP_POLICY string        = STOP_HARD
+	DEFAULT_STOP_GRACE  time.Duration = 5 * time.Minute
+
+	// Run lock lease, renewed while held
+	DEFAULT_LOCK_TTL time.Duration = 15 * time.Minute
+
+	// HTTP API address in daemon mode, local only unless set
+	DEFAULT_LISTEN string = "127.0.0.1:8080"
+
+	// MySQL shutdown types
+	MYSQL_SHUTDOWN_FAST      string = "FAST"
+	MYSQL_SHUTDOWN_SLOW      string = "SLOW"
+	MYSQL_SHUTDOWN_IMMEDIATE string = "IMMEDIATE"
+
+	DEFAULT_MYSQL_SHUTDOWN string = MYSQL_SHUTDOWN_FAST
+
+	// Exadata DB system and VM cluster policies
+	EXADATA_SKIP       string = "skip"      // Refuse to act, record skip reason
+	EXADATA_VM_CLUSTER string = "vmcluster" // Stop/start cluster nodes one at a time
+
+	DEFAULT_EXADATA_POLICY string = EXADATA_SKIP
+
+	// Blast radius actions
+	BLAST_ABORT   string = "abort"   // Abort the run, take no actions
+	BLAST_DRY_RUN string = "dry-run" // Report planned actions, take no actions
+
+	DEFAULT_BLAST_ACTION string = BLAST_ABORT
+
+	// Freeform tag protecting a resource from being turned off
+	DEFAULT_PROTECT_TAG string = "frugal:protect=true"
 )
 
 type LogFunc func(...any) *slog.Logger
@@ -32,28 +69,70 @@ type LogFunc func(...any) *slog.Logger
 // Configuration is responsible for validating and storing any configuration related
 // variables. Default configurations should be set here.
 type Configuration struct {
-	timezone           *time.Location               // Timezone to run script against
-	region             string                       // Region to run script on (Optional)
-	tagNamespace       string                       // Tag Namespace to use, default Schedule
-	schedule           string                       // Scheduler type
-	action             action.Action                // Select action(s) to take
-	principal          string                       // Principal type, Resource Principal if not set
-	provider           common.ConfigurationProvider // Tag Namespace to use, default Schedule
-	privateKeyPassword *string
-	logFunc            func(...any) *slog.Logger
-	logLevel           string
+	timezone              *time.Location               // Timezone to run script against
+	region                string                       // Region to run script on (Optional)
+	tagNamespace          string                       // Tag Namespace to use, default Schedule
+	schedule              string                       // Scheduler type
+	action                action.Action                // Select action(s) to take
+	principal             string                       // Principal type, Resource Principal if not set
+	provider              common.ConfigurationProvider // Tag Namespace to use, default Schedule
+	privateKeyPassword    *string
+	logFunc               func(...any) *slog.Logger
+	logLevel              string
+	waitForState          bool          // Confirm resources reach target state
+	waitTimeout           time.Duration // Time to wait per resource, 0 for default
+	stopPolicy            string        // Compute stop policy
+	stopGrace             time.Duration // Time before graceful stop escalates
+	mysqlShutdown         string        // MySQL DB system shutdown type
+	exadataPolicy         string        // Exadata DB system and VM cluster policy
+	protectedIDs          []string      // Resources never turned off
+	protectedCompartments []string      // Compartments whose resources are never turned off
+	protectTag            string        // Freeform tag key=value protecting a resource
+	maxStops              int           // Maximum stop actions per region per run, 0 unlimited
+	maxStopPercent        float64       // Maximum percent of resources stopped per region per run
+	blastAction           string        // Action when blast radius is exceeded
+	schedules             *ScheduleFile // Shared schedule definitions
+	interval              time.Duration // Time between runs, 0 to evaluate the current hour
+	stateFile             string        // Local file of last run times
+	stateBucket           string        // Object Storage bucket of last run times
+	lockFile              string        // Local file locked while running
+	lockBucket            string        // Object Storage bucket holding the run lock
+	lockTTL               time.Duration // Time before an unrenewed run lock expires
+	listen                string        // HTTP API address in daemon mode
+	apiToken              string        // Bearer token required by the HTTP API
 }
 
 type ConfigurationOpts struct {
-	LogLevel      *string // Default Info
-	Action        *string // Default All
-	TagNamespace  *string // Default Schedule
-	ConfigFile    *string // Default ~/.oci/config
-	ConfigProfile *string // Default DEFAULT
-	KeyPassword   *string // Optional
-	Principal     *string // Default API Key
-	Region        *string // Optional
-	Timezone      *string // Default local timezone
+	LogLevel              *string // Default Info
+	Action                *string // Default All
+	TagNamespace          *string // Default Schedule
+	ConfigFile            *string // Default ~/.oci/config
+	ConfigProfile         *string // Default DEFAULT
+	KeyPassword           *string // Optional
+	Principal             *string // Default API Key
+	Region                *string // Optional
+	Timezone              *string // Default local timezone
+	WaitForState          *string // Default false
+	WaitTimeout           *string // Default handler timeout
+	StopPolicy            *string // Default hard
+	StopGrace             *string // Default 5 Minutes
+	MysqlShutdown         *string // Default FAST
+	ExadataPolicy         *string // Default skip
+	ProtectedIDs          *string // Optional, comma separated OCIDs
+	ProtectedCompartments *string // Optional, comma separated compartment OCIDs
+	ProtectTag            *string // Default frugal:protect=true
+	MaxStops              *string // Default unlimited
+	MaxStopPercent        *string // Default unlimited
+	BlastAction           *string // Default abort
+	ScheduleFile          *string // Optional, path to JSON schedule file
+	Interval              *string // Optional, time between runs
+	StateFile             *string // Optional, path to last run state file
+	StateBucket           *string // Optional, bucket for last run state
+	LockFile              *string // Optional, path to run lock file
+	LockBucket            *string // Optional, bucket for run lock
+	LockTTL               *string // Default 15 Minutes
+	Listen                *string // Default 127.0.0.1:8080
+	APIToken              *string // Required by the serve command
 }
 
 func NewConfiguration(opts ConfigurationOpts) (*Configuration, error) {
@@ -109,6 +188,131 @@ func NewConfiguration(opts ConfigurationOpts) (*Configuration, error) {
 		}
 	}
 
+	// Wait for state variables
+	var wait bool
+	if opts.WaitForState != nil {
+		w, err := strconv.ParseBool(*opts.WaitForState)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wait for state value %s: %w",
+				*opts.WaitForState, err)
+		}
+		wait = w
+	}
+
+	var waitTimeout time.Duration
+	if opts.WaitTimeout != nil {
+		d, err := time.ParseDuration(*opts.WaitTimeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid wait timeout %s", *opts.WaitTimeout)
+		}
+		waitTimeout = d
+	}
+
+	// Compute stop policy variables
+	stopPolicy := DEFAULT_STOP_POLICY
+	if opts.StopPolicy != nil {
+		p, err := ParseStopPolicy(*opts.StopPolicy)
+		if err != nil {
+			return nil, err
+		}
+		stopPolicy = p
+	}
+
+	stopGrace := DEFAULT_STOP_GRACE
+	if opts.StopGrace != nil {
+		d, err := time.ParseDuration(*opts.StopGrace)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid stop grace period %s", *opts.StopGrace)
+		}
+		stopGrace = d
+	}
+
+	mysqlShutdown := DEFAULT_MYSQL_SHUTDOWN
+	if opts.MysqlShutdown != nil {
+		m, err := ParseMysqlShutdown(*opts.MysqlShutdown)
+		if err != nil {
+			return nil, err
+		}
+		mysqlShutdown = m
+	}
+
+	exadataPolicy := DEFAULT_EXADATA_POLICY
+	if opts.ExadataPolicy != nil {
+		e, err := ParseExadataPolicy(*opts.ExadataPolicy)
+		if err != nil {
+			return nil, err
+		}
+		exadataPolicy = e
+	}
+
+	// Protection variables
+	protectTag := DEFAULT_PROTECT_TAG
+	if opts.ProtectTag != nil {
+		protectTag = strings.TrimSpace(*opts.ProtectTag)
+	}
+
+	// Blast radius variables
+	var maxStops int
+	if opts.MaxStops != nil {
+		n, err := strconv.Atoi(*opts.MaxStops)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid max stops %s", *opts.MaxStops)
+		}
+		maxStops = n
+	}
+
+	var maxStopPercent float64
+	if opts.MaxStopPercent != nil {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(*opts.MaxStopPercent, "%"), 64)
+		if err != nil || f < 0 || f > 100 {
+			return nil, fmt.Errorf("invalid max stop percent %s", *opts.MaxStopPercent)
+		}
+		maxStopPercent = f
+	}
+
+	blastAction := DEFAULT_BLAST_ACTION
+	if opts.BlastAction != nil {
+		switch b := strings.ToLower(strings.TrimSpace(*opts.BlastAction)); b {
+		case BLAST_ABORT, BLAST_DRY_RUN:
+			blastAction = b
+		default:
+			return nil, fmt.Errorf("invalid blast action %s", *opts.BlastAction)
+		}
+	}
+
+	// Schedule file variables
+	var interval time.Duration
+	if opts.Interval != nil {
+		d, err := time.ParseDuration(*opts.Interval)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid interval %s", *opts.Interval)
+		}
+		interval = d
+	}
+
+	lockTTL := DEFAULT_LOCK_TTL
+	if opts.LockTTL != nil {
+		d, err := time.ParseDuration(*opts.LockTTL)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid lock ttl %s", *opts.LockTTL)
+		}
+		lockTTL = d
+	}
+
+	listen := DEFAULT_LISTEN
+	if l := valueOf(opts.Listen); l != "" {
+		listen = l
+	}
+
+	schedules := emptyScheduleFile()
+	if opts.ScheduleFile != nil && *opts.ScheduleFile != "" {
+		f, err := LoadScheduleFile(*opts.ScheduleFile)
+		if err != nil {
+			return nil, err
+		}
+		schedules = f
+	}
+
 	// Authentication variables
 	if opts.ConfigFile == nil {
 		opts.ConfigFile = common.String("~/.oci/config")
@@ -157,16 +361,37 @@ func NewConfiguration(opts ConfigurationOpts) (*Configuration, error) {
 	}
 
 	o := Configuration{
-		timezone:           tz,
-		region:             *opts.Region,
-		tagNamespace:       *opts.TagNamespace,
-		schedule:           ANYKEYNL_SCHEDULER,
-		action:             act,
-		principal:          *opts.Principal,
-		provider:           provider,
-		privateKeyPassword: opts.KeyPassword,
-		logFunc:            logFunc,
-		logLevel:           *opts.LogLevel,
+		timezone:              tz,
+		region:                *opts.Region,
+		tagNamespace:          *opts.TagNamespace,
+		schedule:              ANYKEYNL_SCHEDULER,
+		action:                act,
+		principal:             *opts.Principal,
+		provider:              provider,
+		privateKeyPassword:    opts.KeyPassword,
+		logFunc:               logFunc,
+		logLevel:              *opts.LogLevel,
+		waitForState:          wait,
+		waitTimeout:           waitTimeout,
+		stopPolicy:            stopPolicy,
+		stopGrace:             stopGrace,
+		mysqlShutdown:         mysqlShutdown,
+		exadataPolicy:         exadataPolicy,
+		protectedIDs:          splitList(opts.ProtectedIDs),
+		protectedCompartments: splitList(opts.ProtectedCompartments),
+		protectTag:            protectTag,
+		maxStops:              maxStops,
+		maxStopPercent:        maxStopPercent,
+		blastAction:           blastAction,
+		schedules:             schedules,
+		interval:              interval,
+		stateFile:             valueOf(opts.StateFile),
+		stateBucket:           valueOf(opts.StateBucket),
+		lockFile:              valueOf(opts.LockFile),
+		lockBucket:            valueOf(opts.LockBucket),
+		lockTTL:               lockTTL,
+		listen:                listen,
+		apiToken:              valueOf(opts.APIToken),
 	}
 
 	return &o, nil
@@ -215,3 +440,171 @@ func (c *Configuration) MakeLog(v ...any) *slog.Logger {
 func (c *Configuration) LogLevel() string {
 	return c.logLevel
 }
+
+// WaitForState returns true if actions should be confirmed by polling resource state
+func (c *Configuration) WaitForState() bool {
+	return c.waitForState
+}
+
+// WaitTimeout returns the configured time to wait for a resource to reach its
+// target state. Zero means the handler default is used.
+func (c *Configuration) WaitTimeout() time.Duration {
+	return c.waitTimeout
+}
+
+// StopPolicy returns the default compute stop policy [hard, soft, graceful]
+func (c *Configuration) StopPolicy() string {
+	return c.stopPolicy
+}
+
+// StopGrace returns the time a graceful stop waits before powering off
+func (c *Configuration) StopGrace() time.Duration {
+	return c.stopGrace
+}
+
+// ParseStopPolicy validates and normalizes a compute stop policy
+func ParseStopPolicy(s string) (string, error) {
+	switch p := strings.ToLower(strings.TrimSpace(s)); p {
+	case STOP_HARD, STOP_SOFT, STOP_GRACEFUL:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid stop policy %s", s)
+	}
+}
+
+// MysqlShutdown returns the shutdown type used to stop MySQL DB systems
+func (c *Configuration) MysqlShutdown() string {
+	return c.mysqlShutdown
+}
+
+// ParseMysqlShutdown validates and normalizes a MySQL shutdown type
+func ParseMysqlShutdown(s string) (string, error) {
+	switch m := strings.ToUpper(strings.TrimSpace(s)); m {
+	case MYSQL_SHUTDOWN_FAST, MYSQL_SHUTDOWN_SLOW, MYSQL_SHUTDOWN_IMMEDIATE:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid mysql shutdown type %s", s)
+	}
+}
+
+// ExadataPolicy returns how Exadata DB systems and VM clusters are handled
+func (c *Configuration) ExadataPolicy() string {
+	return c.exadataPolicy
+}
+
+// ParseExadataPolicy validates and normalizes an Exadata policy
+func ParseExadataPolicy(s string) (string, error) {
+	switch e := strings.ToLower(strings.TrimSpace(s)); e {
+	case EXADATA_SKIP, EXADATA_VM_CLUSTER:
+		return e, nil
+	default:
+		return "", fmt.Errorf("invalid exadata policy %s", s)
+	}
+}
+
+// ProtectedIDs returns the OCIDs of resources that are never turned off
+func (c *Configuration) ProtectedIDs() []string {
+	return c.protectedIDs
+}
+
+// ProtectedCompartments returns the OCIDs of compartments whose resources, and
+// those of compartments nested within them, are never turned off
+func (c *Configuration) ProtectedCompartments() []string {
+	return c.protectedCompartments
+}
+
+// ProtectTag returns the freeform tag in key=value form that protects a resource
+// from being turned off. Empty disables tag protection.
+func (c *Configuration) ProtectTag() string {
+	return c.protectTag
+}
+
+// splitList splits a comma separated list dropping empty entries
+func splitList(s *string) []string {
+	list := make([]string, 0)
+	if s == nil {
+		return list
+	}
+
+	for _, v := range strings.Split(*s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
+
+// valueOf returns the trimmed value of an optional string, empty if nil
+func valueOf(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(*s)
+}
+
+// MaxStops returns the maximum number of stop actions per region per run, zero
+// for unlimited
+func (c *Configuration) MaxStops() int {
+	return c.maxStops
+}
+
+// MaxStopPercent returns the maximum percent of managed resources stopped per
+// region per run, zero for unlimited
+func (c *Configuration) MaxStopPercent() float64 {
+	return c.maxStopPercent
+}
+
+// BlastAction returns what a run does when it exceeds the blast radius
+// [abort, dry-run]
+func (c *Configuration) BlastAction() string {
+	return c.blastAction
+}
+
+// Schedules returns the shared schedule definitions, empty if no schedule file
+// is configured
+func (c *Configuration) Schedules() *ScheduleFile {
+	return c.schedules
+}
+
+// Interval returns the time between runs. When set, schedules are evaluated for
+// transitions within the interval instead of at the current hour.
+func (c *Configuration) Interval() time.Duration {
+	return c.interval
+}
+
+// StateFile returns the path of the local file of last run times, empty if unset
+func (c *Configuration) StateFile() string {
+	return c.stateFile
+}
+
+// StateBucket returns the Object Storage bucket of last run times, empty if unset
+func (c *Configuration) StateBucket() string {
+	return c.stateBucket
+}
+
+// LockFile returns the path of the local run lock file, empty if unset
+func (c *Configuration) LockFile() string {
+	return c.lockFile
+}
+
+// LockBucket returns the Object Storage bucket holding the run lock, empty if unset
+func (c *Configuration) LockBucket() string {
+	return c.lockBucket
+}
+
+// LockTTL returns the time before a run lock that is not renewed expires
+func (c *Configuration) LockTTL() time.Duration {
+	return c.lockTTL
+}
+
+// Listen returns the address the HTTP API listens on in daemon mode
+func (c *Configuration) Listen() string {
+	return c.listen
+}
+
+// APIToken returns the bearer token required by the HTTP API
+func (c *Configuration) APIToken() string {
+	return c.apiToken
+}
@@ -0,0 +1,67 @@
+package configuration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+)
+
+func TestNewConfiguration_Durations(t *testing.T) {
+	cases := []struct {
+		name        string
+		waitTimeout string
+		stopGrace   string
+		lockTTL     string
+		wantErr     bool
+	}{
+		{"valid", "5m", "2m", "10m", false},
+		{"zero wait timeout", "0s", "2m", "10m", true},
+		{"negative wait timeout", "-1m", "2m", "10m", true},
+		{"zero stop grace", "5m", "0", "10m", true},
+		{"negative stop grace", "5m", "-30s", "10m", true},
+		{"negative lock ttl", "5m", "2m", "-10m", true},
+		{"unparsable", "soon", "2m", "10m", true},
+	}
+
+	for _, c := range cases {
+		cfg, err := NewConfiguration(ConfigurationOpts{
+			ConfigFile:  common.String("testdata/missing"),
+			WaitTimeout: common.String(c.waitTimeout),
+			StopGrace:   common.String(c.stopGrace),
+			LockTTL:     common.String(c.lockTTL),
+		})
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+
+		if !c.wantErr && (cfg.WaitTimeout() != 5*time.Minute ||
+			cfg.StopGrace() != 2*time.Minute || cfg.LockTTL() != 10*time.Minute) {
+			t.Errorf("%s: unexpected durations %s, %s, %s", c.name, cfg.WaitTimeout(),
+				cfg.StopGrace(), cfg.LockTTL())
+		}
+	}
+}
+
+func TestParseStopPolicy(t *testing.T) {
+	cases := map[string]string{
+		"hard":      STOP_HARD,
+		" Soft ":    STOP_SOFT,
+		"GRACEFUL":  STOP_GRACEFUL,
+		"":          "",
+		"shutdown":  "",
+		"hard,soft": "",
+	}
+
+	for in, want := range cases {
+		got, err := ParseStopPolicy(in)
+		if want == "" {
+			if err == nil {
+				t.Errorf("ParseStopPolicy(%q): expected error, got %q", in, got)
+			}
+		} else if err != nil || got != want {
+			t.Errorf("ParseStopPolicy(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ScheduleFile holds schedule definitions shared by many resources. Schedules use
+// the same keys as tags in the tag namespace [ex. AnyDay, WeekDay].
+//
+//	{
+//	  "groups": {
+//	    "payments-dev": {"WeekDay": "0,0,0,0,0,0,0,1,...", "Weekend": "0,0,..."}
+//	  },
+//	  "compartments": {
+//	    "ocid1.compartment.oc1..aaaa": {"AnyDay": "@office-hours"}
+//	  },
+//	  "templates": {
+//	    "weekdays-9to18": "0,0,0,0,0,0,0,0,0,1,1,1,1,1,1,1,1,1,0,0,0,0,0,0"
+//	  }
+//	}
+type ScheduleFile struct {
+	Groups       map[string]map[string]string `json:"groups"`       // Schedule keys by group name
+	Compartments map[string]map[string]string `json:"compartments"` // Default schedule keys by compartment OCID
+	Templates    map[string]string            `json:"templates"`    // Schedules referenced as @name
+}
+
+// LoadScheduleFile reads a JSON schedule file
+func LoadScheduleFile(path string) (*ScheduleFile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading schedule file: %w", err)
+	}
+
+	var f ScheduleFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("error parsing schedule file %s: %w", path, err)
+	}
+
+	if f.Groups == nil {
+		f.Groups = make(map[string]map[string]string)
+	}
+	if f.Compartments == nil {
+		f.Compartments = make(map[string]map[string]string)
+	}
+	if f.Templates == nil {
+		f.Templates = make(map[string]string)
+	}
+
+	return &f, nil
+}
+
+// emptyScheduleFile is used when no schedule file is configured
+func emptyScheduleFile() *ScheduleFile {
+	return &ScheduleFile{
+		Groups:       make(map[string]map[string]string),
+		Compartments: make(map[string]map[string]string),
+		Templates:    make(map[string]string),
+	}
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+)
+
+// ErrBlastRadius indicates a planned action was not taken because the run
+// exceeded its blast radius
+type ErrBlastRadius struct {
+	Reason string
+	DryRun bool
+}
+
+func (e ErrBlastRadius) Error() string {
+	return "blast radius exceeded: " + e.Reason
+}
+
+// BlastRadius limits how many resources a single run may turn off in a region.
+// Zero values disable a limit.
+type BlastRadius struct {
+	MaxStops       int     // Maximum number of OFF actions
+	MaxStopPercent float64 // Maximum OFF actions as a percent of managed resources
+	DryRun         bool    // Report planned actions instead of aborting
+}
+
+// exceeded returns the reason and true if tasks turn off more resources than
+// allowed out of managed resources. Only tasks actionable reports would stop a
+// resource count, so resources already stopped do not.
+func (b BlastRadius) exceeded(tasks []task.Task, managed int,
+	actionable func(task.Task) bool) (string, bool) {
+	stops := 0
+	for _, t := range tasks {
+		if t.Action == action.OFF && actionable(t) {
+			stops++
+		}
+	}
+
+	if b.MaxStops > 0 && stops > b.MaxStops {
+		return fmt.Sprintf("%d stop actions exceeds maximum of %d", stops,
+			b.MaxStops), true
+	}
+
+	if b.MaxStopPercent > 0 && managed > 0 {
+		percent := float64(stops) / float64(managed) * 100
+		if percent > b.MaxStopPercent {
+			return fmt.Sprintf("%d stop actions (%.1f%% of %d resources) exceeds maximum of %.1f%%",
+				stops, percent, managed, b.MaxStopPercent), true
+		}
+	}
+
+	return "", false
+}
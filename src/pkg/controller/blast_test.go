@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+func TestBlastRadius_Exceeded(t *testing.T) {
+	tasks := []task.Task{
+		task.NewTask(action.OFF, rs.ResourceSummary{}),
+		task.NewTask(action.OFF, rs.ResourceSummary{}),
+		task.NewTask(action.OFF, rs.ResourceSummary{}),
+		task.NewTask(action.ON, rs.ResourceSummary{}),
+	}
+
+	cases := []struct {
+		name    string
+		b       BlastRadius
+		managed int
+		want    bool
+	}{
+		{"unlimited", BlastRadius{}, 4, false},
+		{"count within", BlastRadius{MaxStops: 3}, 4, false},
+		{"count exceeded", BlastRadius{MaxStops: 2}, 4, true},
+		{"percent within", BlastRadius{MaxStopPercent: 50}, 10, false},
+		{"percent exceeded", BlastRadius{MaxStopPercent: 25}, 10, true},
+		{"either exceeded", BlastRadius{MaxStops: 10, MaxStopPercent: 10}, 10, true},
+	}
+
+	all := func(task.Task) bool { return true }
+	for _, c := range cases {
+		if _, got := c.b.exceeded(tasks, c.managed, all); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestBlastRadius_ExceededIgnoresStopped(t *testing.T) {
+	resource := func(state string) rs.ResourceSummary {
+		return rs.ResourceSummary{LifecycleState: common.String(state)}
+	}
+
+	// Off hours plan the whole fleet off, most of it already stopped
+	tasks := []task.Task{
+		task.NewTask(action.OFF, resource("STOPPED")),
+		task.NewTask(action.OFF, resource("STOPPED")),
+		task.NewTask(action.OFF, resource("INACTIVE")),
+		task.NewTask(action.OFF, resource("RUNNING")),
+		task.NewTask(action.OFF, resource("ACTIVE")),
+	}
+	running := func(t task.Task) bool {
+		s := *t.Resource.LifecycleState
+		return s == "RUNNING" || s == "ACTIVE"
+	}
+
+	cases := []struct {
+		name string
+		b    BlastRadius
+		want bool
+	}{
+		{"count within", BlastRadius{MaxStops: 2}, false},
+		{"count exceeded", BlastRadius{MaxStops: 1}, true},
+		{"percent within", BlastRadius{MaxStopPercent: 40}, false},
+		{"percent exceeded", BlastRadius{MaxStopPercent: 30}, true},
+	}
+
+	for _, c := range cases {
+		if _, got := c.b.exceeded(tasks, len(tasks), running); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/handler"
+	"github.com/flynnkc/oci-frugal/src/pkg/id"
+)
+
+var (
+	ErrUnknownCompartment   error = errors.New("compartment not found")
+	ErrAmbiguousCompartment error = errors.New("compartment name is not unique, use its OCID")
+)
+
+// Tag keys that configure how a resource is handled rather than its schedule
+var nonScheduleKeys map[string]bool = map[string]bool{
+	handler.STOP_POLICY_KEY: true,
+	START_ORDER_KEY:         true,
+	DEPENDS_ON_KEY:          true,
+	GROUP_SCHEDULE_KEY:      true,
+}
+
+// compartmentDefault is the default schedule of a compartment
+type compartmentDefault struct {
+	name   string
+	parent string
+	keys   map[string]string // Schedule keys, empty if none
+}
+
+// CompartmentDefaults resolves the default schedule a resource inherits from the
+// nearest compartment above it that has one. Defaults are read from compartment
+// defined tags in the tag namespace and from the schedule file, with the
+// schedule file winning for the same compartment.
+type CompartmentDefaults struct {
+	compartments map[string]compartmentDefault
+	hierarchy    bool // Compartment hierarchy was loaded
+}
+
+// NewCompartmentDefaults builds defaults from compartments and their tags in
+// namespace plus schedule file defaults keyed by compartment OCID. Compartments
+// may be empty if the hierarchy is unavailable, in which case only schedule file
+// defaults of a resource's own compartment apply.
+func NewCompartmentDefaults(compartments []id.Compartment, namespace string,
+	file map[string]map[string]string) *CompartmentDefaults {
+	d := CompartmentDefaults{
+		compartments: make(map[string]compartmentDefault, len(compartments)),
+		hierarchy:    len(compartments) > 0,
+	}
+
+	for _, c := range compartments {
+		keys := make(map[string]string)
+		for k, v := range c.DefinedTags[namespace] {
+			if v != nil && !nonScheduleKeys[k] {
+				keys[k] = strings.TrimSpace(fmt.Sprint(v))
+			}
+		}
+
+		d.compartments[c.ID] = compartmentDefault{
+			name:   c.Name,
+			parent: c.ParentID,
+			keys:   keys,
+		}
+	}
+
+	for ocid, keys := range file {
+		c := d.compartments[ocid]
+		if c.name == "" {
+			c.name = ocid
+		}
+		c.keys = keys
+		d.compartments[ocid] = c
+	}
+
+	return &d
+}
+
+// Resolve returns the schedule keys and name of the nearest compartment with a
+// default schedule, starting with the compartment itself
+func (d *CompartmentDefaults) Resolve(compartmentID string) (map[string]string,
+	string, bool) {
+	if d == nil {
+		return nil, "", false
+	}
+
+	// Bound the walk in case of a malformed hierarchy
+	for range len(d.compartments) + 1 {
+		c, ok := d.compartments[compartmentID]
+		if !ok {
+			return nil, "", false
+		}
+		if len(c.keys) > 0 {
+			return c.keys, c.name, true
+		}
+		if c.parent == "" {
+			return nil, "", false
+		}
+		compartmentID = c.parent
+	}
+
+	return nil, "", false
+}
+
+// Lookup returns the OCID of a compartment by OCID or name. OCIDs are accepted
+// without lookup when the hierarchy is unavailable.
+func (d *CompartmentDefaults) Lookup(compartment string) (string, error) {
+	if d != nil {
+		if _, ok := d.compartments[compartment]; ok {
+			return compartment, nil
+		}
+
+		found := ""
+		for ocid, c := range d.compartments {
+			if c.name != compartment {
+				continue
+			}
+			if found != "" {
+				return "", fmt.Errorf("%w: %s", ErrAmbiguousCompartment, compartment)
+			}
+			found = ocid
+		}
+		if found != "" {
+			return found, nil
+		}
+	}
+
+	if strings.HasPrefix(compartment, "ocid1.") {
+		return compartment, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrUnknownCompartment, compartment)
+}
+
+// HasHierarchy returns true if the compartment hierarchy was loaded, false if
+// only direct compartment matches are possible
+func (d *CompartmentDefaults) HasHierarchy() bool {
+	return d != nil && d.hierarchy
+}
+
+// Within returns true if a compartment is ancestor or beneath it
+func (d *CompartmentDefaults) Within(compartmentID, ancestor string) bool {
+	if compartmentID == ancestor {
+		return true
+	}
+	if d == nil {
+		return false
+	}
+
+	// Bound the walk in case of a malformed hierarchy
+	for range len(d.compartments) + 1 {
+		c, ok := d.compartments[compartmentID]
+		if !ok || c.parent == "" {
+			return false
+		}
+		if c.parent == ancestor {
+			return true
+		}
+		compartmentID = c.parent
+	}
+
+	return false
+}
+
+// hasSchedule returns true if tags contain any schedule keys
+func hasSchedule(tags map[string]interface{}) bool {
+	for k, v := range tags {
+		if v != nil && !nonScheduleKeys[k] {
+			return true
+		}
+	}
+
+	return false
+}
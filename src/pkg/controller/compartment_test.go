@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/id"
+)
+
+func TestCompartmentDefaults_Resolve(t *testing.T) {
+	compartments := []id.Compartment{
+		{ID: "root", Name: "tenancy"},
+		{ID: "dev", Name: "dev", ParentID: "root", DefinedTags: map[string]map[string]interface{}{
+			"Schedule": {"WeekDay": "office", "StartOrder": "10"},
+		}},
+		{ID: "team", Name: "dev-team", ParentID: "dev"},
+		{ID: "prod", Name: "prod", ParentID: "root", DefinedTags: map[string]map[string]interface{}{
+			"Schedule": {"StopPolicy": "soft"},
+		}},
+	}
+	file := map[string]map[string]string{
+		"ops": {"AnyDay": "ops"},
+	}
+
+	d := NewCompartmentDefaults(compartments, "Schedule", file)
+
+	cases := []struct {
+		compartment string
+		from        string
+		ok          bool
+	}{
+		{"dev", "dev", true},
+		{"team", "dev", true}, // Nearest ancestor
+		{"prod", "", false},   // Only non-schedule keys
+		{"root", "", false},   // No default
+		{"ops", "ops", true},  // Schedule file without hierarchy
+		{"other", "", false},  // Unknown compartment
+	}
+
+	for _, c := range cases {
+		keys, from, ok := d.Resolve(c.compartment)
+		if ok != c.ok || from != c.from {
+			t.Errorf("%s: got %q %v, want %q %v", c.compartment, from, ok, c.from, c.ok)
+		}
+		if ok && len(keys) != 1 {
+			t.Errorf("%s: expected only schedule keys, got %v", c.compartment, keys)
+		}
+	}
+}
+
+func TestCompartmentDefaults_Scope(t *testing.T) {
+	compartments := []id.Compartment{
+		{ID: "root", Name: "tenancy"},
+		{ID: "dev", Name: "dev", ParentID: "root"},
+		{ID: "team", Name: "dev-team", ParentID: "dev"},
+		{ID: "a", Name: "shared", ParentID: "dev"},
+		{ID: "b", Name: "shared", ParentID: "root"},
+	}
+	d := NewCompartmentDefaults(compartments, "Schedule", nil)
+
+	if ocid, err := d.Lookup("dev-team"); err != nil || ocid != "team" {
+		t.Fatalf("expected team by name, got %q, %v", ocid, err)
+	}
+	if ocid, err := d.Lookup("dev"); err != nil || ocid != "dev" {
+		t.Fatalf("expected dev by OCID, got %q, %v", ocid, err)
+	}
+	if _, err := d.Lookup("shared"); !errors.Is(err, ErrAmbiguousCompartment) {
+		t.Fatalf("expected ErrAmbiguousCompartment, got %v", err)
+	}
+	if _, err := d.Lookup("missing"); !errors.Is(err, ErrUnknownCompartment) {
+		t.Fatalf("expected ErrUnknownCompartment, got %v", err)
+	}
+
+	cases := []struct {
+		compartment string
+		ancestor    string
+		want        bool
+	}{
+		{"dev", "dev", true},
+		{"team", "dev", true},
+		{"team", "root", true},
+		{"b", "dev", false},
+		{"dev", "team", false},
+		{"other", "dev", false},
+	}
+	for _, c := range cases {
+		if got := d.Within(c.compartment, c.ancestor); got != c.want {
+			t.Fatalf("Within(%q, %q) = %v, want %v", c.compartment, c.ancestor, got, c.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/flynnkc/oci-frugal/src/pkg/action"
 	"github.com/flynnkc/oci-frugal/src/pkg/configuration"
@@ -12,6 +13,7 @@ import (
 
 var (
 	ErrControllerOptions error = fmt.Errorf("missing one or more required options on controller")
+	ErrRunCancelled      error = fmt.Errorf("run cancelled")
 )
 
 type Controller interface {
@@ -29,4 +31,15 @@ type ControllerOpts struct {
 	Scheduler             scheduler.Scheduler
 	SupportedActions      action.Action
 	LogFunc               configuration.LogFunc
+	WaitForState          bool                 // Confirm actions reach target state
+	WaitTimeout           time.Duration        // Optional, handler default if unset
+	StopPolicy            string               // Optional, compute stop policy
+	StopGrace             time.Duration        // Optional, graceful stop escalation
+	MysqlShutdown         string               // Optional, MySQL shutdown type
+	ExadataPolicy         string               // Optional, Exadata handling
+	ProtectedIDs          []string             // Optional, resources never turned off
+	ProtectedCompartments []string             // Optional, compartments never turned off
+	ProtectTag            string               // Optional, freeform key=value protecting resources
+	BlastRadius           BlastRadius          // Optional, limits stop actions per run
+	CompartmentDefaults   *CompartmentDefaults // Optional, inherited schedules
 }
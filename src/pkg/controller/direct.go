@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+var (
+	ErrInvalidIdentifier error = errors.New("invalid resource identifier")
+)
+
+// IdentifierQuery returns the structured search query for resources of the search
+// types with any of ids
+func IdentifierQuery(types, ids []string) (string, error) {
+	conditions := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" || strings.ContainsAny(id, "'\\ ") {
+			return "", fmt.Errorf("%w: %q", ErrInvalidIdentifier, id)
+		}
+		conditions = append(conditions, fmt.Sprintf("identifier = '%s'", id))
+	}
+
+	return fmt.Sprintf("query %s resources where %s return allAdditionalFields",
+		strings.Join(types, ", "),
+		strings.Join(conditions, " || ")), nil
+}
+
+// Direct plans act on resources with ids and every supported resource in the
+// controller's compartment, bypassing schedules. The plan is executed like a
+// scheduled one so protection, the blast radius, ordering, and rate limits apply.
+// The blast radius is measured against every supported resource in the region.
+func (tc *TagController) Direct(act action.Action, ids []string) (*Plan, error) {
+	types := tc.handler.SearchTypes()
+	collection, err := tc.Search(Query(types))
+	if err != nil {
+		return nil, fmt.Errorf("error searching for resources: %w", err)
+	}
+	managed := len(collection.Items)
+
+	items := make([]rs.ResourceSummary, 0)
+	if tc.compartment != "" {
+		items = append(items, tc.scope(collection.Items)...)
+	}
+
+	if len(ids) > 0 {
+		query, err := IdentifierQuery(types, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		collection, err := tc.Search(query)
+		if err != nil {
+			return nil, fmt.Errorf("error searching for resources: %w", err)
+		}
+		items = append(items, collection.Items...)
+	}
+
+	return tc.directPlan(act, managed, items), nil
+}
+
+// directPlan plans act on each of items once out of managed resources
+func (tc *TagController) directPlan(act action.Action, managed int,
+	items []rs.ResourceSummary) *Plan {
+	plan := newPlan(tc.region, managed)
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		if item.Identifier == nil || seen[*item.Identifier] {
+			continue
+		}
+		seen[*item.Identifier] = true
+
+		t := task.NewTask(act, item)
+		t.Reason = fmt.Sprintf("requested %s", act)
+
+		if reason, ok := tc.protected(t); ok {
+			t.Reason = reason
+			plan.Blocked = append(plan.Blocked, t)
+			continue
+		}
+
+		plan.Tasks = append(plan.Tasks, t)
+	}
+
+	tc.log.Info("Planned requested run",
+		slog.String("Action", act.String()),
+		slog.Int("Managed", plan.Managed),
+		slog.Int("Tasks", len(plan.Tasks)),
+		slog.Int("Blocked", len(plan.Blocked)))
+
+	return plan
+}
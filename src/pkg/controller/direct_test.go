@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+func TestIdentifierQuery(t *testing.T) {
+	q, err := IdentifierQuery([]string{"instance", "dbsystem"},
+		[]string{"ocid1.instance.oc1..a", "ocid1.dbsystem.oc1..b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "where identifier = 'ocid1.instance.oc1..a' || identifier = 'ocid1.dbsystem.oc1..b' return"
+	if !strings.HasPrefix(q, "query instance, dbsystem resources ") ||
+		!strings.Contains(q, want) {
+		t.Fatalf("unexpected query %q", q)
+	}
+
+	for _, bad := range []string{"", "ocid1' || identifier = 'x", "ocid1 a"} {
+		if _, err := IdentifierQuery([]string{"instance"}, []string{bad}); !errors.Is(err, ErrInvalidIdentifier) {
+			t.Fatalf("%q: expected ErrInvalidIdentifier, got %v", bad, err)
+		}
+	}
+}
+
+func TestTagController_DirectPlan(t *testing.T) {
+	protection, err := NewProtection([]string{"ocid1.instance.oc1..protected"}, nil, "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tc := TagController{
+		region:     "us-ashburn-1",
+		protection: protection,
+		log:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	item := func(id string) rs.ResourceSummary {
+		return rs.ResourceSummary{Identifier: common.String(id),
+			ResourceType: common.String("Instance")}
+	}
+	items := []rs.ResourceSummary{
+		item("ocid1.instance.oc1..a"),
+		item("ocid1.instance.oc1..b"),
+		item("ocid1.instance.oc1..a"),
+		item("ocid1.instance.oc1..protected"),
+	}
+
+	plan := tc.directPlan(action.OFF, 20, items)
+	if plan.Managed != 20 {
+		t.Fatalf("expected plan of 20 managed resources, got %d", plan.Managed)
+	}
+	if len(plan.Tasks) != 2 || len(plan.Blocked) != 1 {
+		t.Fatalf("expected 2 tasks and 1 blocked, got %d and %d", len(plan.Tasks),
+			len(plan.Blocked))
+	}
+
+	// Requested stops are a share of the region rather than all of the plan
+	all := func(task.Task) bool { return true }
+	if _, ok := (BlastRadius{MaxStopPercent: 25}).exceeded(plan.Tasks, plan.Managed, all); ok {
+		t.Fatalf("expected 2 of 20 resources within 25%% blast radius")
+	}
+	if _, ok := (BlastRadius{MaxStopPercent: 5}).exceeded(plan.Tasks, plan.Managed, all); !ok {
+		t.Fatalf("expected 2 of 20 resources to exceed 5%% blast radius")
+	}
+}
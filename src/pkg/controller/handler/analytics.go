@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/oracle/oci-go-sdk/v65/analytics"
+	"github.com/oracle/oci-go-sdk/v65/common"
+)
+
+func init() {
+	RegisterPaaS(PaaSService{
+		Name:          "Analytics Instance",
+		SearchType:    "analyticsinstance",
+		ResourceType:  "AnalyticsInstance",
+		RunningStates: []string{string(analytics.AnalyticsInstanceLifecycleStateActive)},
+		StoppedStates: []string{string(analytics.AnalyticsInstanceLifecycleStateInactive)},
+		NewClient: func(cp common.ConfigurationProvider) (PaaSClient, error) {
+			c, err := analytics.NewAnalyticsClientWithConfigurationProvider(cp)
+			return &analyticsClient{c}, err
+		},
+	})
+}
+
+type analyticsClient struct {
+	analytics.AnalyticsClient
+}
+
+func (c *analyticsClient) Start(ctx context.Context, id *string) (*http.Response, error) {
+	resp, err := c.StartAnalyticsInstance(ctx,
+		analytics.StartAnalyticsInstanceRequest{AnalyticsInstanceId: id})
+	return resp.RawResponse, err
+}
+
+func (c *analyticsClient) Stop(ctx context.Context, id *string) (*http.Response, error) {
+	resp, err := c.StopAnalyticsInstance(ctx,
+		analytics.StopAnalyticsInstanceRequest{AnalyticsInstanceId: id})
+	return resp.RawResponse, err
+}
+
+func (c *analyticsClient) State(ctx context.Context, id *string) (string, *http.Response, error) {
+	resp, err := c.GetAnalyticsInstance(ctx,
+		analytics.GetAnalyticsInstanceRequest{AnalyticsInstanceId: id})
+	return string(resp.LifecycleState), resp.RawResponse, err
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+)
+
+func init() {
+	Register(Registration{
+		Name:         "Autonomous Database",
+		SearchType:   "autonomousdatabase",
+		ResourceType: "AutonomousDatabase",
+		New:          newSearchOnlyService,
+	})
+}
+
+// searchOnlyService is found by resource search and counted toward the blast
+// radius but supports no actions, so its resources are never started or stopped
+type searchOnlyService struct{}
+
+func newSearchOnlyService(*ResourceHandler) (Service, error) {
+	return searchOnlyService{}, nil
+}
+
+func (searchOnlyService) Actions() action.Action {
+	return action.NULL_ACTION
+}
+
+func (searchOnlyService) SetRegion(string) {}
+
+func (searchOnlyService) CanStop(string) bool {
+	return false
+}
+
+func (searchOnlyService) CanStart(string) bool {
+	return false
+}
+
+func (searchOnlyService) Stop(task.Task) error {
+	return nil
+}
+
+func (searchOnlyService) Start(task.Task) error {
+	return nil
+}
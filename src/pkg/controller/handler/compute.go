@@ -0,0 +1,305 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/configuration"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+func init() {
+	Register(Registration{
+		Name:         "Compute",
+		SearchType:   "instance",
+		ResourceType: "Instance",
+		New:          newComputeService,
+	})
+}
+
+// computeService takes actions on compute resources. Limited to turning instance
+// on or off -- no vertical scaling supported at this time.
+type computeService struct {
+	h       *ResourceHandler
+	client  computeClient
+	pools   computeManagementClient
+	members map[string]map[string]string // Pool OCID by instance OCID by compartment
+	mu      sync.Mutex                   // Guards members
+}
+
+// computeClient is the part of core.ComputeClient used to power instances on and
+// off
+type computeClient interface {
+	SetRegion(string)
+	InstanceAction(context.Context, core.InstanceActionRequest) (core.InstanceActionResponse, error)
+	GetInstance(context.Context, core.GetInstanceRequest) (core.GetInstanceResponse, error)
+}
+
+func newComputeService(h *ResourceHandler) (Service, error) {
+	c, err := core.NewComputeClientWithConfigurationProvider(h.configProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := core.NewComputeManagementClientWithConfigurationProvider(h.configProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	return &computeService{
+		h:       h,
+		client:  &c,
+		pools:   &p,
+		members: make(map[string]map[string]string),
+	}, nil
+}
+
+func (s *computeService) Actions() action.Action {
+	return action.ON | action.OFF
+}
+
+func (s *computeService) SetRegion(region string) {
+	s.client.SetRegion(region)
+	s.pools.SetRegion(region)
+}
+
+func (s *computeService) CanStop(state string) bool {
+	return state != "STOPPED" &&
+		state != "STOPPING" &&
+		state != "TERMINATING" &&
+		state != "TERMINATED"
+}
+
+func (s *computeService) CanStart(state string) bool {
+	return state != "RUNNING" &&
+		state != "STARTING" &&
+		state != "TERMINATING" &&
+		state != "TERMINATED"
+}
+
+// Stop powers off the instance using the resource's stop policy
+func (s *computeService) Stop(t task.Task) error {
+	logGroup := getResourceGroup(t)
+	policy := s.h.computeStopPolicy(t)
+
+	act := core.InstanceActionActionStop
+	if policy != configuration.STOP_HARD {
+		act = core.InstanceActionActionSoftstop
+	}
+
+	resp, err := s.instanceAction(logGroup, t.Resource.Identifier, act)
+	if err != nil {
+		return err
+	}
+	s.h.log.Info("Compute Handled",
+		slog.String("Action", string(act)),
+		slog.String("Stop Policy", policy),
+		slog.String("Status Message", resp.RawResponse.Status),
+		logGroup)
+
+	if policy == configuration.STOP_GRACEFUL {
+		return s.h.escalate(t, s.escalateStop)
+	}
+
+	return s.h.confirm(t, s.instanceState(t.Resource.Identifier),
+		string(core.InstanceLifecycleStateStopped))
+}
+
+// Start powers on the instance
+func (s *computeService) Start(t task.Task) error {
+	logGroup := getResourceGroup(t)
+	resp, err := s.instanceAction(logGroup, t.Resource.Identifier,
+		core.InstanceActionActionStart)
+	if err != nil {
+		return err
+	}
+	s.h.log.Info("Compute Handled",
+		slog.String("Action", "START"),
+		slog.String("Status Message", resp.RawResponse.Status),
+		logGroup)
+
+	return s.h.confirm(t, s.instanceState(t.Resource.Identifier),
+		string(core.InstanceLifecycleStateRunning))
+}
+
+// WaitFor waits for a starting or stopping instance to reach its target state
+func (s *computeService) WaitFor(t task.Task) error {
+	target := core.InstanceLifecycleStateStopped
+	if t.Action == action.ON {
+		target = core.InstanceLifecycleStateRunning
+	}
+
+	return s.h.confirm(t, s.instanceState(t.Resource.Identifier), string(target))
+}
+
+// Skip leaves instances managed by an instance pool untouched. Pool members are
+// handled through their instance pool, acting on them individually causes the
+// pool to replace or restart them.
+func (s *computeService) Skip(t task.Task) error {
+	pool, err := s.instancePoolOf(t)
+	if err != nil {
+		return err
+	} else if pool != "" {
+		return ErrSkipped{Reason: fmt.Sprintf("instance is managed by instance pool %s",
+			pool)}
+	}
+
+	return nil
+}
+
+// instancePoolOf returns the OCID of the instance pool managing an instance or
+// an empty string if it is standalone. Members of the pools in the instance's
+// compartment are listed once per compartment.
+func (s *computeService) instancePoolOf(t task.Task) (string, error) {
+	if t.Resource.CompartmentId == nil {
+		return "", nil
+	}
+	compartment := *t.Resource.CompartmentId
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members, ok := s.members[compartment]
+	if !ok {
+		var err error
+		members, err = s.poolMembers(getResourceGroup(t), t.Resource.CompartmentId)
+		if err != nil {
+			return "", fmt.Errorf("list instance pool members: %w", err)
+		}
+		s.members[compartment] = members
+	}
+
+	return members[*t.Resource.Identifier], nil
+}
+
+// poolMembers returns the pool OCID of every instance in the instance pools of a
+// compartment by instance OCID
+func (s *computeService) poolMembers(logGroup slog.Attr,
+	compartment *string) (map[string]string, error) {
+	members := make(map[string]string)
+
+	req := core.ListInstancePoolsRequest{CompartmentId: compartment}
+	for {
+		var resp core.ListInstancePoolsResponse
+		err := s.h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+			var err error
+			resp, err = s.pools.ListInstancePools(ctx, req)
+			return resp.RawResponse, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pool := range resp.Items {
+			if pool.Id == nil ||
+				pool.LifecycleState == core.InstancePoolSummaryLifecycleStateTerminated {
+				continue
+			}
+
+			if err := s.listPoolInstances(logGroup, compartment, pool.Id,
+				members); err != nil {
+				return nil, err
+			}
+		}
+
+		if resp.OpcNextPage == nil {
+			break
+		}
+		req.Page = resp.OpcNextPage
+	}
+
+	return members, nil
+}
+
+// listPoolInstances adds the instances of a pool to members
+func (s *computeService) listPoolInstances(logGroup slog.Attr, compartment,
+	pool *string, members map[string]string) error {
+	req := core.ListInstancePoolInstancesRequest{
+		CompartmentId:  compartment,
+		InstancePoolId: pool,
+	}
+
+	for {
+		var resp core.ListInstancePoolInstancesResponse
+		err := s.h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+			var err error
+			resp, err = s.pools.ListInstancePoolInstances(ctx, req)
+			return resp.RawResponse, err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, instance := range resp.Items {
+			if instance.Id != nil {
+				members[*instance.Id] = *pool
+			}
+		}
+
+		if resp.OpcNextPage == nil {
+			return nil
+		}
+		req.Page = resp.OpcNextPage
+	}
+}
+
+// instanceAction sends a power action to a compute instance
+func (s *computeService) instanceAction(logGroup slog.Attr, id *string,
+	act core.InstanceActionActionEnum) (core.InstanceActionResponse, error) {
+	req := core.InstanceActionRequest{
+		InstanceId: id,
+		Action:     act,
+	}
+
+	var resp core.InstanceActionResponse
+	err := s.h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+		var err error
+		resp, err = s.client.InstanceAction(ctx, req)
+		return resp.RawResponse, err
+	})
+
+	return resp, err
+}
+
+// escalateStop waits for a soft stopped instance to reach STOPPED, sending a hard
+// stop if it has not done so within the grace period
+func (s *computeService) escalateStop(t task.Task) error {
+	logGroup := getResourceGroup(t)
+	id := t.Resource.Identifier
+
+	err := s.h.waitForState(logGroup, s.h.stopGrace, s.instanceState(id),
+		string(core.InstanceLifecycleStateStopped))
+	if !errors.Is(err, ErrWaitTimeout) {
+		return err
+	}
+
+	s.h.log.Warn("Instance did not stop within grace period, escalating to hard stop",
+		slog.Duration("Grace Period", s.h.stopGrace),
+		logGroup)
+
+	resp, err := s.instanceAction(logGroup, id, core.InstanceActionActionStop)
+	if err != nil {
+		return fmt.Errorf("escalate to hard stop: %w", err)
+	}
+	s.h.log.Info("Compute Handled",
+		slog.String("Action", string(core.InstanceActionActionStop)),
+		slog.String("Status Message", resp.RawResponse.Status),
+		logGroup)
+
+	return s.h.confirm(t, s.instanceState(id),
+		string(core.InstanceLifecycleStateStopped))
+}
+
+// instanceState returns a getter for the lifecycle state of a compute instance
+func (s *computeService) instanceState(id *string) stateGetter {
+	return func(ctx context.Context) (string, *http.Response, error) {
+		resp, err := s.client.GetInstance(ctx, core.GetInstanceRequest{InstanceId: id})
+		return string(resp.LifecycleState), resp.RawResponse, err
+	}
+}
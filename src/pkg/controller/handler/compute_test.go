@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/configuration"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+// fakeComputeClient records instance actions. A soft stopped instance stops only
+// if softStops is set, a hard stopped instance always stops.
+type fakeComputeClient struct {
+	mu        sync.Mutex
+	softStops bool
+	state     core.InstanceLifecycleStateEnum
+	actions   []core.InstanceActionActionEnum
+}
+
+func (c *fakeComputeClient) SetRegion(string) {}
+
+func (c *fakeComputeClient) InstanceAction(_ context.Context,
+	req core.InstanceActionRequest) (core.InstanceActionResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.actions = append(c.actions, req.Action)
+	switch {
+	case req.Action == core.InstanceActionActionStop,
+		req.Action == core.InstanceActionActionSoftstop && c.softStops:
+		c.state = core.InstanceLifecycleStateStopped
+	default:
+		c.state = core.InstanceLifecycleStateStopping
+	}
+
+	return core.InstanceActionResponse{
+		RawResponse: &http.Response{Status: "200 OK", StatusCode: http.StatusOK},
+	}, nil
+}
+
+func (c *fakeComputeClient) GetInstance(context.Context,
+	core.GetInstanceRequest) (core.GetInstanceResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return core.GetInstanceResponse{
+		RawResponse: &http.Response{StatusCode: http.StatusOK},
+		Instance:    core.Instance{LifecycleState: c.state},
+	}, nil
+}
+
+func instanceTask(tags map[string]interface{}) task.Task {
+	return task.NewTask(action.OFF, rs.ResourceSummary{
+		Identifier:     common.String("ocid1.instance.oc1..aaaa"),
+		ResourceType:   common.String("Instance"),
+		LifecycleState: common.String("RUNNING"),
+		DefinedTags:    map[string]map[string]interface{}{"Schedule": tags},
+	})
+}
+
+func TestComputeStopPolicy_Tag(t *testing.T) {
+	h := &ResourceHandler{
+		log:          slog.Default(),
+		tagNamespace: "Schedule",
+		stopPolicy:   configuration.STOP_HARD,
+	}
+
+	cases := []struct {
+		name string
+		tags map[string]interface{}
+		want string
+	}{
+		{"no tag", nil, configuration.STOP_HARD},
+		{"soft", map[string]interface{}{STOP_POLICY_KEY: "soft"}, configuration.STOP_SOFT},
+		{"case insensitive", map[string]interface{}{STOP_POLICY_KEY: " Graceful "},
+			configuration.STOP_GRACEFUL},
+		{"invalid falls back", map[string]interface{}{STOP_POLICY_KEY: "shutdown"},
+			configuration.STOP_HARD},
+		{"nil value", map[string]interface{}{STOP_POLICY_KEY: nil}, configuration.STOP_HARD},
+	}
+
+	for _, c := range cases {
+		if got := h.computeStopPolicy(instanceTask(c.tags)); got != c.want {
+			t.Errorf("%s: got %s, want %s", c.name, got, c.want)
+		}
+	}
+
+	// Tags in other namespaces are ignored
+	other := instanceTask(nil)
+	other.Resource.DefinedTags = map[string]map[string]interface{}{
+		"Other": {STOP_POLICY_KEY: "soft"},
+	}
+	if got := h.computeStopPolicy(other); got != configuration.STOP_HARD {
+		t.Errorf("other namespace: got %s, want %s", got, configuration.STOP_HARD)
+	}
+}
+
+func TestCompute_GracefulStop(t *testing.T) {
+	cases := []struct {
+		name      string
+		policy    string
+		softStops bool
+		want      []core.InstanceActionActionEnum
+	}{
+		{"hard", configuration.STOP_HARD, false,
+			[]core.InstanceActionActionEnum{core.InstanceActionActionStop}},
+		{"soft does not escalate", configuration.STOP_SOFT, false,
+			[]core.InstanceActionActionEnum{core.InstanceActionActionSoftstop}},
+		{"graceful stops within grace", configuration.STOP_GRACEFUL, true,
+			[]core.InstanceActionActionEnum{core.InstanceActionActionSoftstop}},
+		{"graceful escalates", configuration.STOP_GRACEFUL, false,
+			[]core.InstanceActionActionEnum{core.InstanceActionActionSoftstop,
+				core.InstanceActionActionStop}},
+	}
+
+	for _, c := range cases {
+		client := &fakeComputeClient{softStops: c.softStops,
+			state: core.InstanceLifecycleStateRunning}
+		s := &computeService{
+			h: &ResourceHandler{
+				log:          slog.Default(),
+				retryPolicy:  DefaultRetryPolicy(),
+				tagNamespace: "Schedule",
+				stopPolicy:   c.policy,
+				stopGrace:    5 * time.Millisecond,
+				waitTimeout:  time.Second,
+				pollInterval: time.Millisecond,
+			},
+			client: client,
+		}
+
+		if err := s.Stop(instanceTask(nil)); err != nil {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+			continue
+		}
+		s.h.Wait()
+		if !slices.Equal(client.actions, c.want) {
+			t.Errorf("%s: expected actions %v, got %v", c.name, c.want, client.actions)
+		}
+	}
+}
+
+func TestCompute_GracefulStopInBackground(t *testing.T) {
+	client := &fakeComputeClient{state: core.InstanceLifecycleStateRunning}
+	s := &computeService{
+		h: &ResourceHandler{
+			log:          slog.Default(),
+			retryPolicy:  DefaultRetryPolicy(),
+			tagNamespace: "Schedule",
+			stopPolicy:   configuration.STOP_GRACEFUL,
+			stopGrace:    200 * time.Millisecond,
+			waitTimeout:  time.Second,
+			pollInterval: time.Millisecond,
+		},
+		client: client,
+	}
+
+	// Without wait-for-state the worker does not block for the grace period
+	start := time.Now()
+	if err := s.Stop(instanceTask(nil)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= s.h.stopGrace {
+		t.Fatalf("expected stop to return before the grace period, took %s", elapsed)
+	}
+
+	s.h.Wait()
+	want := []core.InstanceActionActionEnum{core.InstanceActionActionSoftstop,
+		core.InstanceActionActionStop}
+	if !slices.Equal(client.actions, want) {
+		t.Fatalf("expected actions %v, got %v", want, client.actions)
+	}
+
+	// Tasks that must reach their state wait for the escalation
+	client.actions = nil
+	client.state = core.InstanceLifecycleStateRunning
+	waited := instanceTask(nil)
+	waited.Wait = true
+	if err := s.Stop(waited); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(client.actions, want) {
+		t.Fatalf("expected escalation before returning, got %v", client.actions)
+	}
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	ds "github.com/oracle/oci-go-sdk/v65/datascience"
+)
+
+var (
+	// Data Science notebook sessions are activated and deactivated
+	notebookSessions PaaSService = PaaSService{
+		Name:          "Notebook Session",
+		SearchType:    "datasciencenotebooksession",
+		ResourceType:  "DataScienceNotebookSession",
+		RunningStates: []string{string(ds.NotebookSessionLifecycleStateActive)},
+		StoppedStates: []string{string(ds.NotebookSessionLifecycleStateInactive)},
+		NewClient: func(cp common.ConfigurationProvider) (PaaSClient, error) {
+			c, err := ds.NewDataScienceClientWithConfigurationProvider(cp)
+			return &notebookSessionClient{&c}, err
+		},
+	}
+
+	// Data Science model deployments are activated and deactivated
+	modelDeployments PaaSService = PaaSService{
+		Name:         "Model Deployment",
+		SearchType:   "datasciencemodeldeployment",
+		ResourceType: "DataScienceModelDeployment",
+		RunningStates: []string{string(ds.ModelDeploymentLifecycleStateActive),
+			string(ds.ModelDeploymentLifecycleStateNeedsAttention)},
+		StoppedStates: []string{string(ds.ModelDeploymentLifecycleStateInactive)},
+		NewClient: func(cp common.ConfigurationProvider) (PaaSClient, error) {
+			c, err := ds.NewDataScienceClientWithConfigurationProvider(cp)
+			return &modelDeploymentClient{&c}, err
+		},
+	}
+)
+
+func init() {
+	RegisterPaaS(notebookSessions)
+	RegisterPaaS(modelDeployments)
+}
+
+// dataScienceClient is the part of ds.DataScienceClient used to activate and
+// deactivate notebook sessions and model deployments
+type dataScienceClient interface {
+	SetRegion(string)
+	ActivateNotebookSession(context.Context, ds.ActivateNotebookSessionRequest) (ds.ActivateNotebookSessionResponse, error)
+	DeactivateNotebookSession(context.Context, ds.DeactivateNotebookSessionRequest) (ds.DeactivateNotebookSessionResponse, error)
+	GetNotebookSession(context.Context, ds.GetNotebookSessionRequest) (ds.GetNotebookSessionResponse, error)
+	ActivateModelDeployment(context.Context, ds.ActivateModelDeploymentRequest) (ds.ActivateModelDeploymentResponse, error)
+	DeactivateModelDeployment(context.Context, ds.DeactivateModelDeploymentRequest) (ds.DeactivateModelDeploymentResponse, error)
+	GetModelDeployment(context.Context, ds.GetModelDeploymentRequest) (ds.GetModelDeploymentResponse, error)
+}
+
+type notebookSessionClient struct {
+	dataScienceClient
+}
+
+func (c *notebookSessionClient) Start(ctx context.Context, id *string) (*http.Response, error) {
+	resp, err := c.ActivateNotebookSession(ctx,
+		ds.ActivateNotebookSessionRequest{NotebookSessionId: id})
+	return resp.RawResponse, err
+}
+
+func (c *notebookSessionClient) Stop(ctx context.Context, id *string) (*http.Response, error) {
+	resp, err := c.DeactivateNotebookSession(ctx,
+		ds.DeactivateNotebookSessionRequest{NotebookSessionId: id})
+	return resp.RawResponse, err
+}
+
+func (c *notebookSessionClient) State(ctx context.Context, id *string) (string, *http.Response, error) {
+	resp, err := c.GetNotebookSession(ctx, ds.GetNotebookSessionRequest{NotebookSessionId: id})
+	return string(resp.LifecycleState), resp.RawResponse, err
+}
+
+type modelDeploymentClient struct {
+	dataScienceClient
+}
+
+func (c *modelDeploymentClient) Start(ctx context.Context, id *string) (*http.Response, error) {
+	resp, err := c.ActivateModelDeployment(ctx,
+		ds.ActivateModelDeploymentRequest{ModelDeploymentId: id})
+	return resp.RawResponse, err
+}
+
+func (c *modelDeploymentClient) Stop(ctx context.Context, id *string) (*http.Response, error) {
+	resp, err := c.DeactivateModelDeployment(ctx,
+		ds.DeactivateModelDeploymentRequest{ModelDeploymentId: id})
+	return resp.RawResponse, err
+}
+
+func (c *modelDeploymentClient) State(ctx context.Context, id *string) (string, *http.Response, error) {
+	resp, err := c.GetModelDeployment(ctx, ds.GetModelDeploymentRequest{ModelDeploymentId: id})
+	return string(resp.LifecycleState), resp.RawResponse, err
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	tokenpool "github.com/flynnkc/token-pool"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	ds "github.com/oracle/oci-go-sdk/v65/datascience"
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+// fakeDataScienceClient records activations and reports the state they settle in
+type fakeDataScienceClient struct {
+	state  string
+	events []string
+}
+
+func (c *fakeDataScienceClient) SetRegion(string) {}
+
+func (c *fakeDataScienceClient) ActivateNotebookSession(context.Context,
+	ds.ActivateNotebookSessionRequest) (ds.ActivateNotebookSessionResponse, error) {
+	c.events, c.state = append(c.events, "activate notebook"), "ACTIVE"
+	return ds.ActivateNotebookSessionResponse{RawResponse: okResponse()}, nil
+}
+
+func (c *fakeDataScienceClient) DeactivateNotebookSession(context.Context,
+	ds.DeactivateNotebookSessionRequest) (ds.DeactivateNotebookSessionResponse, error) {
+	c.events, c.state = append(c.events, "deactivate notebook"), "INACTIVE"
+	return ds.DeactivateNotebookSessionResponse{RawResponse: okResponse()}, nil
+}
+
+func (c *fakeDataScienceClient) GetNotebookSession(context.Context,
+	ds.GetNotebookSessionRequest) (ds.GetNotebookSessionResponse, error) {
+	c.events = append(c.events, "get notebook")
+	resp := ds.GetNotebookSessionResponse{RawResponse: okResponse()}
+	resp.LifecycleState = ds.NotebookSessionLifecycleStateEnum(c.state)
+	return resp, nil
+}
+
+func (c *fakeDataScienceClient) ActivateModelDeployment(context.Context,
+	ds.ActivateModelDeploymentRequest) (ds.ActivateModelDeploymentResponse, error) {
+	c.events, c.state = append(c.events, "activate deployment"), "ACTIVE"
+	return ds.ActivateModelDeploymentResponse{RawResponse: okResponse()}, nil
+}
+
+func (c *fakeDataScienceClient) DeactivateModelDeployment(context.Context,
+	ds.DeactivateModelDeploymentRequest) (ds.DeactivateModelDeploymentResponse, error) {
+	c.events, c.state = append(c.events, "deactivate deployment"), "INACTIVE"
+	return ds.DeactivateModelDeploymentResponse{RawResponse: okResponse()}, nil
+}
+
+func (c *fakeDataScienceClient) GetModelDeployment(context.Context,
+	ds.GetModelDeploymentRequest) (ds.GetModelDeploymentResponse, error) {
+	c.events = append(c.events, "get deployment")
+	resp := ds.GetModelDeploymentResponse{RawResponse: okResponse()}
+	resp.LifecycleState = ds.ModelDeploymentLifecycleStateEnum(c.state)
+	return resp, nil
+}
+
+func TestDataScience_LifecycleGuards(t *testing.T) {
+	h := &ResourceHandler{
+		log:          slog.Default(),
+		retryPolicy:  DefaultRetryPolicy(),
+		wait:         true,
+		waitTimeout:  time.Second,
+		pollInterval: time.Millisecond,
+		tp:           tokenpool.NewTokenPool(8, 8, time.Second),
+	}
+
+	cases := []struct {
+		desc   PaaSService
+		act    action.Action
+		state  string
+		events []string
+	}{
+		{notebookSessions, action.OFF, "ACTIVE", []string{"deactivate notebook", "get notebook"}},
+		{notebookSessions, action.ON, "INACTIVE", []string{"activate notebook", "get notebook"}},
+		{notebookSessions, action.OFF, "INACTIVE", nil},
+		{notebookSessions, action.ON, "ACTIVE", nil},
+		{notebookSessions, action.OFF, "NEEDS_ATTENTION", nil},
+		{modelDeployments, action.OFF, "ACTIVE", []string{"deactivate deployment", "get deployment"}},
+		{modelDeployments, action.OFF, "NEEDS_ATTENTION", []string{"deactivate deployment", "get deployment"}},
+		{modelDeployments, action.ON, "INACTIVE", []string{"activate deployment", "get deployment"}},
+		{modelDeployments, action.ON, "UPDATING", nil},
+		{modelDeployments, action.OFF, "DELETED", nil},
+	}
+
+	for _, c := range cases {
+		client := &fakeDataScienceClient{state: c.state}
+		var pc PaaSClient = &notebookSessionClient{client}
+		if c.desc.ResourceType == modelDeployments.ResourceType {
+			pc = &modelDeploymentClient{client}
+		}
+		h.services = map[string]serviceCache{"": {
+			c.desc.ResourceType: {svc: &paasService{h: h, desc: c.desc, client: pc}},
+		}}
+
+		err := h.HandleResource(task.NewTask(c.act, rs.ResourceSummary{
+			Identifier:     common.String("ocid1.datascience.oc1..aaaa"),
+			ResourceType:   common.String(c.desc.ResourceType),
+			LifecycleState: common.String(c.state),
+		}))
+		if err != nil {
+			t.Errorf("%s %v %s: unexpected error: %v", c.desc.Name, c.act, c.state, err)
+		}
+		if !slices.Equal(client.events, c.events) {
+			t.Errorf("%s %v %s: expected %v, got %v", c.desc.Name, c.act, c.state,
+				c.events, client.events)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/configuration"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/database"
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+func init() {
+	Register(Registration{
+		Name:         "DB System",
+		SearchType:   "dbsystem",
+		ResourceType: "DbSystem",
+		New:          newDbSystemService,
+	})
+}
+
+// dbSystemService starts or stops Database Node resources of a DB System.
+// Exadata DB systems are handled per the Exadata policy.
+type dbSystemService struct {
+	dbNodeHandler
+}
+
+// dbNodeHandler starts or stops the Database Nodes of a DB system or VM cluster
+type dbNodeHandler struct {
+	h        *ResourceHandler
+	database databaseClient
+}
+
+// databaseClient is the part of database.DatabaseClient used to act on Database
+// Nodes
+type databaseClient interface {
+	SetRegion(string)
+	ListDbNodes(context.Context, database.ListDbNodesRequest) (database.ListDbNodesResponse, error)
+	GetDbNode(context.Context, database.GetDbNodeRequest) (database.GetDbNodeResponse, error)
+	DbNodeAction(context.Context, database.DbNodeActionRequest) (database.DbNodeActionResponse, error)
+}
+
+func newDbSystemService(h *ResourceHandler) (Service, error) {
+	db, err := database.NewDatabaseClientWithConfigurationProvider(h.configProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dbSystemService{dbNodeHandler{h: h, database: &db}}, nil
+}
+
+func (s *dbSystemService) Actions() action.Action {
+	return action.ON | action.OFF
+}
+
+func (s *dbNodeHandler) SetRegion(region string) {
+	s.database.SetRegion(region)
+}
+
+// Node states are checked individually when handling the DB System
+func (s *dbSystemService) CanStop(state string) bool {
+	return state != "TERMINATING" && state != "TERMINATED"
+}
+
+func (s *dbSystemService) CanStart(state string) bool {
+	return state != "TERMINATING" && state != "TERMINATED"
+}
+
+// Skip leaves Exadata DB systems untouched unless the Exadata policy handles them
+func (s *dbSystemService) Skip(t task.Task) error {
+	if shape, exadata := exadataShape(t); exadata &&
+		s.h.exadataPolicy != configuration.EXADATA_VM_CLUSTER {
+		return ErrSkipped{Reason: fmt.Sprintf(
+			"DB system has Exadata shape %s and exadata policy is %s", shape,
+			s.h.exadataPolicy)}
+	}
+
+	return nil
+}
+
+// NeedsAction returns true if any node of the DB system is not already in the
+// target state
+func (s *dbSystemService) NeedsAction(t task.Task) (bool, error) {
+	return s.needsAction(t, database.ListDbNodesRequest{
+		DbSystemId: t.Resource.Identifier,
+	})
+}
+
+func (s *dbSystemService) Stop(t task.Task) error {
+	return s.handle(t)
+}
+
+func (s *dbSystemService) Start(t task.Task) error {
+	return s.handle(t)
+}
+
+// handle acts on the nodes of the DB system. Nodes of Exadata DB systems are
+// handled one at a time.
+func (s *dbSystemService) handle(t task.Task) error {
+	if err := s.Skip(t); err != nil {
+		return err
+	}
+	_, exadata := exadataShape(t)
+
+	nodes, err := s.getDbNodes(t, database.ListDbNodesRequest{
+		DbSystemId: t.Resource.Identifier,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.handleNodes(t, nodes, exadata)
+}
+
+// exadataShape returns the shape reported by search and true if it is an
+// Exadata shape
+func exadataShape(t task.Task) (string, bool) {
+	shape, ok := t.Resource.AdditionalDetails["shape"].(string)
+	if !ok {
+		return "", false
+	}
+
+	return shape, strings.HasPrefix(strings.ToLower(shape), "exadata")
+}
+
+// handleNodes starts or stops each Database Node. Actions are sent to every node
+// before waiting on them together. Rolling handles one node at a time, waiting
+// for each to reach its target state before moving on and stopping at the first
+// failure.
+func (s *dbNodeHandler) handleNodes(t task.Task, nodes []rs.ResourceSummary,
+	rolling bool) error {
+	var errs []error
+	pending := make([]confirmation, 0, len(nodes))
+
+	for _, node := range nodes {
+		if rolling && len(errs) > 0 {
+			break
+		}
+
+		// Rolling mode waits for each node regardless of wait-for-state
+		nodeTask := task.NewTask(t.Action, node)
+		nodeTask.Wait = t.Wait || rolling
+
+		c, err := s.nodeAction(t, nodeTask)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		} else if c == nil {
+			continue
+		}
+
+		if rolling {
+			errs = append(errs, s.h.confirmAll([]confirmation{*c})...)
+		} else {
+			pending = append(pending, *c)
+		}
+	}
+
+	errs = append(errs, s.h.confirmAll(pending)...)
+	if len(errs) > 0 {
+		return fmt.Errorf(
+			"%s %s: one or more DB node actions failed: %w",
+			str(t.Resource.ResourceType), str(t.Resource.Identifier), errors.Join(errs...),
+		)
+	}
+
+	return nil
+}
+
+// nodeAction starts or stops a Database Node of t if its lifecycle state allows,
+// returning the confirmation of its target state or nil if no action was taken
+func (s *dbNodeHandler) nodeAction(t, nodeTask task.Task) (*confirmation, error) {
+	node := nodeTask.Resource
+	state := *node.LifecycleState
+	logGroup := getResourceGroup(nodeTask)
+	s.h.log.Debug("Handling DB Node", logGroup)
+
+	if !nodeActionable(t.Action, state) {
+		s.h.log.Info("DB Node Handled - No Action Required",
+			slog.String("State", state),
+			slog.String("Action", "NONE"), logGroup)
+		return nil, nil
+	}
+
+	act := database.DbNodeActionActionStop
+	target := database.DbNodeLifecycleStateStopped
+	if t.Action == action.ON {
+		act = database.DbNodeActionActionStart
+		target = database.DbNodeLifecycleStateAvailable
+	}
+
+	req := database.DbNodeActionRequest{
+		DbNodeId: node.Identifier,
+		Action:   act,
+	}
+
+	var resp database.DbNodeActionResponse
+	err := s.h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+		var err error
+		resp, err = s.database.DbNodeAction(ctx, req)
+		return resp.RawResponse, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s dbnode %s (parent %s, state %s) failed: %w",
+			strings.ToLower(string(act)), str(node.Identifier),
+			str(t.Resource.Identifier), state, err)
+	}
+
+	s.h.log.Info("Handled DB Node",
+		slog.String("Action", string(act)),
+		slog.String("Status", resp.RawResponse.Status),
+		logGroup)
+
+	return &confirmation{
+		t:       nodeTask,
+		get:     s.dbNodeState(node.Identifier),
+		targets: []string{string(target)},
+	}, nil
+}
+
+// needsAction returns true if any node matching req would be started or stopped
+func (s *dbNodeHandler) needsAction(t task.Task, req database.ListDbNodesRequest) (bool, error) {
+	nodes, err := s.getDbNodes(t, req)
+	if err != nil {
+		return false, err
+	}
+
+	return slices.ContainsFunc(nodes, func(node rs.ResourceSummary) bool {
+		return nodeActionable(t.Action, *node.LifecycleState)
+	}), nil
+}
+
+// nodeActionable returns true if a Database Node in the lifecycle state is
+// stopped by OFF or started by ON
+func nodeActionable(act action.Action, state string) bool {
+	switch state {
+	case string(database.DbNodeLifecycleStateTerminating),
+		string(database.DbNodeLifecycleStateTerminated):
+		return false
+	}
+
+	switch act {
+	case action.OFF:
+		return state != string(database.DbNodeLifecycleStateStopped) &&
+			state != string(database.DbNodeLifecycleStateStopping)
+	case action.ON:
+		return state != string(database.DbNodeLifecycleStateAvailable) &&
+			state != string(database.DbNodeLifecycleStateStarting)
+	}
+
+	return false
+}
+
+// str safely dereferences pointers for error messages
+func str(p *string) string {
+	if p == nil {
+		return "<nil>"
+	}
+	return *p
+}
+
+// dbNodeState returns a getter for the lifecycle state of a database node
+func (s *dbNodeHandler) dbNodeState(id *string) stateGetter {
+	return func(ctx context.Context) (string, *http.Response, error) {
+		resp, err := s.database.GetDbNode(ctx, database.GetDbNodeRequest{DbNodeId: id})
+		return string(resp.LifecycleState), resp.RawResponse, err
+	}
+}
+
+// getDbNodes lists every database node matching req page by page. Any failed
+// request, non-2xx response, or incomplete node is returned as an error so that
+// no node is silently left out of an action.
+func (s *dbNodeHandler) getDbNodes(t task.Task,
+	req database.ListDbNodesRequest) ([]rs.ResourceSummary, error) {
+	nodes := make([]rs.ResourceSummary, 0)
+	id := t.Resource.Identifier
+	logGroup := getResourceGroup(t)
+
+	if t.Resource.CompartmentId == nil {
+		return nodes, fmt.Errorf("%s %s: missing compartment",
+			*t.Resource.ResourceType, *id)
+	}
+
+	s.h.log.Debug("Listing DB Nodes",
+		slog.String("ID", *id))
+
+	req.CompartmentId = t.Resource.CompartmentId
+	req.Limit = common.Int(1000)
+
+	// Pagination by breaking when no next page
+	for {
+		var resp database.ListDbNodesResponse
+		err := s.h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+			var err error
+			resp, err = s.database.ListDbNodes(ctx, req)
+			return resp.RawResponse, err
+		})
+		if err != nil {
+			return nodes, fmt.Errorf("list dbnodes of %s: %w", *id, err)
+		} else if resp.RawResponse.StatusCode > 299 || resp.RawResponse.StatusCode < 200 {
+			return nodes, fmt.Errorf("list dbnodes of %s: invalid status %s",
+				*id, resp.RawResponse.Status)
+		}
+
+		for _, item := range resp.Items {
+			if item.Id == nil || item.LifecycleState == "" {
+				return nodes, fmt.Errorf("%s: dbnode missing identifier or state", *id)
+			}
+
+			s.h.log.Debug("appending item",
+				slog.String("Parent", *id),
+				slog.String("Node", *item.Id))
+			nodes = append(nodes, rs.ResourceSummary{
+				Identifier:     item.Id,
+				ResourceType:   common.String("DbNode"),
+				DisplayName:    item.Hostname,
+				CompartmentId:  t.Resource.CompartmentId,
+				LifecycleState: common.String(string(item.LifecycleState)),
+			})
+		}
+
+		if resp.OpcNextPage == nil {
+			break
+		}
+		req.Page = resp.OpcNextPage
+	}
+
+	if len(nodes) == 0 {
+		return nodes, fmt.Errorf("%s: no dbnodes found", *id)
+	}
+
+	s.h.log.Debug("Returning DB Nodes",
+		slog.String("Parent", *id),
+		slog.Int("Count", len(nodes)))
+
+	return nodes, nil
+}
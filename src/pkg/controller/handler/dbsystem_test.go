@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/configuration"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/database"
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+// fakeDatabaseClient serves pages of DB nodes and records node actions and state
+// polls in the order they are made
+type fakeDatabaseClient struct {
+	mu     sync.Mutex
+	pages  [][]database.DbNodeSummary
+	states map[string]string // Current state by node OCID
+	settle map[string]string // State a node enters after an action, default target
+	events []string
+}
+
+func newFakeDatabaseClient(nodes ...string) *fakeDatabaseClient {
+	c := &fakeDatabaseClient{
+		states: make(map[string]string),
+		settle: make(map[string]string),
+	}
+
+	page := make([]database.DbNodeSummary, 0, len(nodes))
+	for _, id := range nodes {
+		page = append(page, database.DbNodeSummary{
+			Id:             common.String(id),
+			LifecycleState: database.DbNodeSummaryLifecycleStateAvailable,
+		})
+		c.states[id] = "AVAILABLE"
+	}
+	c.pages = [][]database.DbNodeSummary{page}
+
+	return c
+}
+
+func (c *fakeDatabaseClient) SetRegion(string) {}
+
+func (c *fakeDatabaseClient) ListDbNodes(_ context.Context,
+	req database.ListDbNodesRequest) (database.ListDbNodesResponse, error) {
+	i := 0
+	if req.Page != nil {
+		i, _ = strconv.Atoi(*req.Page)
+	}
+
+	resp := database.ListDbNodesResponse{
+		RawResponse: &http.Response{StatusCode: http.StatusOK},
+	}
+	if i < len(c.pages) {
+		resp.Items = c.pages[i]
+	}
+	if i+1 < len(c.pages) {
+		resp.OpcNextPage = common.String(strconv.Itoa(i + 1))
+	}
+
+	return resp, nil
+}
+
+func (c *fakeDatabaseClient) GetDbNode(_ context.Context,
+	req database.GetDbNodeRequest) (database.GetDbNodeResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.events = append(c.events, "get "+*req.DbNodeId)
+	return database.GetDbNodeResponse{
+		RawResponse: &http.Response{StatusCode: http.StatusOK},
+		DbNode: database.DbNode{
+			LifecycleState: database.DbNodeLifecycleStateEnum(c.states[*req.DbNodeId]),
+		},
+	}, nil
+}
+
+func (c *fakeDatabaseClient) DbNodeAction(_ context.Context,
+	req database.DbNodeActionRequest) (database.DbNodeActionResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := *req.DbNodeId
+	c.events = append(c.events, "action "+id)
+	switch {
+	case c.settle[id] != "":
+		c.states[id] = c.settle[id]
+	case req.Action == database.DbNodeActionActionStop:
+		c.states[id] = "STOPPED"
+	default:
+		c.states[id] = "AVAILABLE"
+	}
+
+	return database.DbNodeActionResponse{
+		RawResponse: &http.Response{Status: "200 OK", StatusCode: http.StatusOK},
+	}, nil
+}
+
+func testDbSystemService(client *fakeDatabaseClient, policy string) *dbSystemService {
+	return &dbSystemService{dbNodeHandler{
+		h: &ResourceHandler{
+			log:           slog.Default(),
+			retryPolicy:   DefaultRetryPolicy(),
+			waitTimeout:   time.Second,
+			pollInterval:  time.Millisecond,
+			exadataPolicy: policy,
+		},
+		database: client,
+	}}
+}
+
+func dbSystemTask(act action.Action, shape string) task.Task {
+	return task.NewTask(act, rs.ResourceSummary{
+		Identifier:        common.String("ocid1.dbsystem.oc1..aaaa"),
+		ResourceType:      common.String("DbSystem"),
+		CompartmentId:     common.String("ocid1.compartment.oc1..dev"),
+		LifecycleState:    common.String("AVAILABLE"),
+		AdditionalDetails: map[string]interface{}{"shape": shape},
+	})
+}
+
+func TestDbSystem_ExadataRolling(t *testing.T) {
+	client := newFakeDatabaseClient("n1", "n2", "n3")
+	s := testDbSystemService(client, configuration.EXADATA_VM_CLUSTER)
+
+	if err := s.Stop(dbSystemTask(action.OFF, "Exadata.X9M")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Each node is confirmed stopped before the next is acted on, even though
+	// wait-for-state is disabled
+	want := []string{"action n1", "get n1", "action n2", "get n2", "action n3", "get n3"}
+	if !slices.Equal(client.events, want) {
+		t.Fatalf("expected %v, got %v", want, client.events)
+	}
+}
+
+func TestDbSystem_ExadataRollingStopsAtFailure(t *testing.T) {
+	client := newFakeDatabaseClient("n1", "n2", "n3")
+	client.settle["n1"] = "FAILED"
+	s := testDbSystemService(client, configuration.EXADATA_VM_CLUSTER)
+
+	err := s.Stop(dbSystemTask(action.OFF, "Exadata.X9M"))
+	if !errors.As(err, &ErrUnexpectedState{}) {
+		t.Fatalf("expected ErrUnexpectedState, got %v", err)
+	}
+
+	want := []string{"action n1", "get n1"}
+	if !slices.Equal(client.events, want) {
+		t.Fatalf("expected remaining nodes untouched, got %v", client.events)
+	}
+}
+
+func TestDbSystem_ExadataSkipped(t *testing.T) {
+	client := newFakeDatabaseClient("n1")
+	s := testDbSystemService(client, configuration.EXADATA_SKIP)
+
+	if err := s.Stop(dbSystemTask(action.OFF, "Exadata.X9M")); !errors.As(err, &ErrSkipped{}) {
+		t.Fatalf("expected ErrSkipped, got %v", err)
+	}
+	if len(client.events) != 0 {
+		t.Fatalf("expected no node actions, got %v", client.events)
+	}
+}
+
+func TestDbSystem_ActionsBeforeConfirm(t *testing.T) {
+	client := newFakeDatabaseClient("n1", "n2", "n3")
+	client.pages[0][1].LifecycleState = database.DbNodeSummaryLifecycleStateStopped
+	client.states["n2"] = "STOPPED"
+	s := testDbSystemService(client, configuration.EXADATA_SKIP)
+	s.h.wait = true
+
+	if err := s.Stop(dbSystemTask(action.OFF, "VM.Standard.E4.Flex")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Stopped nodes are left alone and every action is sent before any wait
+	if len(client.events) != 4 || !slices.Equal(client.events[:2],
+		[]string{"action n1", "action n3"}) {
+		t.Fatalf("expected actions on n1 and n3 before confirming, got %v", client.events)
+	}
+}
+
+func TestDbSystem_StartAvailableNodes(t *testing.T) {
+	client := newFakeDatabaseClient("n1", "n2")
+	client.pages[0][1].LifecycleState = database.DbNodeSummaryLifecycleStateStopped
+	client.states["n2"] = "STOPPED"
+	s := testDbSystemService(client, configuration.EXADATA_SKIP)
+
+	if err := s.Start(dbSystemTask(action.ON, "VM.Standard.E4.Flex")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Nodes already AVAILABLE are not started again
+	if want := []string{"action n2"}; !slices.Equal(client.events, want) {
+		t.Fatalf("expected %v, got %v", want, client.events)
+	}
+}
+
+func TestSearchTypes_VmClusterPolicy(t *testing.T) {
+	h := &ResourceHandler{exadataPolicy: configuration.EXADATA_SKIP}
+	for _, st := range []string{"cloudvmcluster", "vmcluster"} {
+		if slices.Contains(h.SearchTypes(), st) {
+			t.Errorf("expected %s not to be searched with policy %s", st, h.exadataPolicy)
+		}
+	}
+
+	h.exadataPolicy = configuration.EXADATA_VM_CLUSTER
+	for _, st := range []string{"cloudvmcluster", "vmcluster", "dbsystem"} {
+		if !slices.Contains(h.SearchTypes(), st) {
+			t.Errorf("expected %s to be searched with policy %s", st, h.exadataPolicy)
+		}
+	}
+}
+
+func TestGetDbNodes(t *testing.T) {
+	node := func(id string) database.DbNodeSummary {
+		return database.DbNodeSummary{
+			Id:             common.String(id),
+			LifecycleState: database.DbNodeSummaryLifecycleStateAvailable,
+		}
+	}
+
+	cases := []struct {
+		name    string
+		pages   [][]database.DbNodeSummary
+		want    []string
+		wantErr bool
+	}{
+		{"single page", [][]database.DbNodeSummary{{node("n1"), node("n2")}},
+			[]string{"n1", "n2"}, false},
+		{"multiple pages", [][]database.DbNodeSummary{{node("n1")}, {node("n2"), node("n3")},
+			{node("n4")}}, []string{"n1", "n2", "n3", "n4"}, false},
+		{"empty last page", [][]database.DbNodeSummary{{node("n1")}, {}},
+			[]string{"n1"}, false},
+		{"no nodes", [][]database.DbNodeSummary{{}}, nil, true},
+		{"no nodes across pages", [][]database.DbNodeSummary{{}, {}}, nil, true},
+		{"node missing state", [][]database.DbNodeSummary{{node("n1"),
+			{Id: common.String("n2")}}}, nil, true},
+	}
+
+	for _, c := range cases {
+		client := &fakeDatabaseClient{pages: c.pages}
+		s := testDbSystemService(client, configuration.EXADATA_SKIP)
+
+		nodes, err := s.getDbNodes(dbSystemTask(action.OFF, ""),
+			database.ListDbNodesRequest{DbSystemId: common.String("ocid1.dbsystem.oc1..aaaa")})
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		} else if c.wantErr {
+			continue
+		}
+
+		ids := make([]string, 0, len(nodes))
+		for _, n := range nodes {
+			ids = append(ids, *n.Identifier)
+		}
+		if !slices.Equal(ids, c.want) {
+			t.Errorf("%s: expected nodes %v, got %v", c.name, c.want, ids)
+		}
+	}
+
+	// Nodes cannot be listed without the compartment of their parent
+	s := testDbSystemService(newFakeDatabaseClient("n1"), configuration.EXADATA_SKIP)
+	tk := dbSystemTask(action.OFF, "")
+	tk.Resource.CompartmentId = nil
+	if _, err := s.getDbNodes(tk, database.ListDbNodesRequest{}); err == nil {
+		t.Errorf("expected error for missing compartment")
+	}
+}
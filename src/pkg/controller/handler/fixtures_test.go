@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+// registerTestService registers a resource type for the duration of a test
+func registerTestService(t *testing.T, r Registration) {
+	t.Helper()
+
+	Register(r)
+	t.Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+
+		delete(registry, r.ResourceType)
+	})
+}
+
+func testService() PaaSService {
+	return PaaSService{
+		Name:          "Test Service",
+		SearchType:    "testservice",
+		ResourceType:  "TestService",
+		RunningStates: []string{"ACTIVE"},
+		StoppedStates: []string{"INACTIVE"},
+	}
+}
+
+func testTask(act action.Action, state string) task.Task {
+	return task.NewTask(act, rs.ResourceSummary{
+		Identifier:     common.String("ocid1.test.oc1..aaaa"),
+		ResourceType:   common.String("TestService"),
+		LifecycleState: common.String(state),
+	})
+}
+
+func okResponse() *http.Response {
+	return &http.Response{Status: "200 OK", StatusCode: http.StatusOK}
+}
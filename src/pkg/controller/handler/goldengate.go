@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	gg "github.com/oracle/oci-go-sdk/v65/goldengate"
+)
+
+func init() {
+	RegisterPaaS(PaaSService{
+		Name:          "GoldenGate Deployment",
+		SearchType:    "goldengatedeployment",
+		ResourceType:  "GoldenGateDeployment",
+		RunningStates: []string{string(gg.LifecycleStateActive), string(gg.LifecycleStateNeedsAttention)},
+		StoppedStates: []string{string(gg.LifecycleStateInactive)},
+		NewClient: func(cp common.ConfigurationProvider) (PaaSClient, error) {
+			c, err := gg.NewGoldenGateClientWithConfigurationProvider(cp)
+			return &goldenGateClient{c}, err
+		},
+	})
+}
+
+type goldenGateClient struct {
+	gg.GoldenGateClient
+}
+
+func (c *goldenGateClient) Start(ctx context.Context, id *string) (*http.Response, error) {
+	resp, err := c.StartDeployment(ctx, gg.StartDeploymentRequest{
+		DeploymentId:           id,
+		StartDeploymentDetails: gg.DefaultStartDeploymentDetails{},
+	})
+	return resp.RawResponse, err
+}
+
+func (c *goldenGateClient) Stop(ctx context.Context, id *string) (*http.Response, error) {
+	resp, err := c.StopDeployment(ctx, gg.StopDeploymentRequest{
+		DeploymentId:          id,
+		StopDeploymentDetails: gg.DefaultStopDeploymentDetails{},
+	})
+	return resp.RawResponse, err
+}
+
+func (c *goldenGateClient) State(ctx context.Context, id *string) (string, *http.Response, error) {
+	resp, err := c.GetDeployment(ctx, gg.GetDeploymentRequest{DeploymentId: id})
+	return string(resp.LifecycleState), resp.RawResponse, err
+}
@@ -2,32 +2,45 @@ package handler
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/configuration"
 	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
 	tokenpool "github.com/flynnkc/token-pool"
-	"github.com/oracle/oci-go-sdk/v65/analytics"
 	"github.com/oracle/oci-go-sdk/v65/common"
-	"github.com/oracle/oci-go-sdk/v65/core"
-	"github.com/oracle/oci-go-sdk/v65/database"
-	"github.com/oracle/oci-go-sdk/v65/integration"
-	"github.com/oracle/oci-go-sdk/v65/resourcesearch"
-	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+	"github.com/oracle/oci-go-sdk/v65/mysql"
 )
 
 const (
+	STOP_POLICY_KEY      string        = "StopPolicy" // Tag key overriding stop policy
 	DEFAULT_INTERVAL     time.Duration = 15 * time.Second
 	MAX_INTERVAL         time.Duration = 3 * time.Minute
 	DEFAULT_MAX_REQUESTS int           = 8
 )
 
+// ErrSkipped indicates a resource was deliberately left untouched
+type ErrSkipped struct {
+	Reason string
+}
+
+func (e ErrSkipped) Error() string {
+	return "skipped: " + e.Reason
+}
+
 type Handler interface {
 	HandleResource(task.Task) error
 	SetRegion(string)
+	// Actionable returns true if the task would start or stop its resource
+	Actionable(task.Task) bool
+	// SearchTypes returns the resource search types the handler acts on
+	SearchTypes() []string
+	// Wait blocks until actions finishing in the background are done
+	Wait()
 }
 
 type HandlerOpts struct {
@@ -35,16 +48,33 @@ type HandlerOpts struct {
 	Logger          *slog.Logger
 	MaxRequests     *int
 	RequestInterval *time.Duration // 1-30 Seconds
+	Retry           *RetryPolicy   // Default DefaultRetryPolicy
+	WaitForState    *bool          // Default false
+	WaitTimeout     *time.Duration // Default 10 Minutes
+	TagNamespace    *string        // Namespace holding per resource options
+	StopPolicy      *string        // Default hard
+	StopGrace       *time.Duration // Default 5 Minutes
+	MysqlShutdown   *string        // Default FAST
+	ExadataPolicy   *string        // Default skip
 }
 
 type ResourceHandler struct {
-	analytics   analytics.AnalyticsClient
-	compute     core.ComputeClient
-	database    database.DatabaseClient
-	integration integration.IntegrationInstanceClient
-	search      resourcesearch.ResourceSearchClient
-	log         *slog.Logger
-	tp          *tokenpool.TokenPool
+	configProvider common.ConfigurationProvider
+	region         string
+	services       map[string]serviceCache // Services built on first use by region
+	mu             sync.Mutex              // Guards region and services
+	log            *slog.Logger
+	tp             *tokenpool.TokenPool
+	retryPolicy    RetryPolicy
+	wait           bool          // Confirm resources reach target state
+	waitTimeout    time.Duration // Maximum time to confirm a single resource
+	pollInterval   time.Duration // Time between lifecycle state checks
+	tagNamespace   string
+	stopPolicy     string                       // Default compute stop policy
+	stopGrace      time.Duration                // Time before graceful stop powers off
+	mysqlShutdown  mysql.InnoDbShutdownModeEnum // MySQL stop shutdown type
+	exadataPolicy  string                       // Exadata DB system and VM cluster handling
+	escalations    sync.WaitGroup               // Graceful stops escalating in the background
 }
 
 func NewResourceHandler(opts HandlerOpts) (*ResourceHandler, error) {
@@ -70,332 +100,280 @@ func NewResourceHandler(opts HandlerOpts) (*ResourceHandler, error) {
 
 	h.tp = tokenpool.NewTokenPool(*opts.MaxRequests, *opts.MaxRequests, *opts.RequestInterval)
 
-	if opts.ConfigProvider == nil {
-		return nil, fmt.Errorf("error Handler cannot have nil ConfigProvider")
-	}
-
-	cp := opts.ConfigProvider
-
-	// Compute
-	instance, err := core.NewComputeClientWithConfigurationProvider(cp)
-	if err != nil {
-		return nil, err
-	}
-	h.compute = instance
-
-	// Database
-	db, err := database.NewDatabaseClientWithConfigurationProvider(cp)
-	if err != nil {
-		return nil, err
+	if opts.Retry != nil {
+		h.retryPolicy = *opts.Retry
+	} else {
+		h.retryPolicy = DefaultRetryPolicy()
 	}
-	h.database = db
 
-	// Analytics Cloud
-	a, err := analytics.NewAnalyticsClientWithConfigurationProvider(cp)
-	if err != nil {
-		return nil, err
+	if opts.WaitForState != nil {
+		h.wait = *opts.WaitForState
 	}
-	h.analytics = a
 
-	// Integration Cloud
-	i, err := integration.NewIntegrationInstanceClientWithConfigurationProvider(cp)
-	if err != nil {
-		return nil, err
+	if opts.WaitTimeout != nil {
+		h.waitTimeout = *opts.WaitTimeout
+	} else {
+		h.waitTimeout = DEFAULT_WAIT_TIMEOUT
 	}
-	h.integration = i
+	h.pollInterval = DEFAULT_POLL_INTERVAL
 
-	// Search (Required for DbSystems)
-	s, err := rs.NewResourceSearchClientWithConfigurationProvider(cp)
-	if err != nil {
-		return nil, err
+	if opts.TagNamespace != nil {
+		h.tagNamespace = *opts.TagNamespace
 	}
-	h.search = s
-
-	return &h, nil
-}
 
-func (h *ResourceHandler) SetRegion(region string) {
-	h.analytics.SetRegion(region)
-	h.compute.SetRegion(region)
-	h.database.SetRegion(region)
-	h.integration.SetRegion(region)
-	h.search.SetRegion(region)
-}
-
-// HandleResource routes task to the appropriate handler
-func (h *ResourceHandler) HandleResource(t task.Task) error {
-	h.log.Debug("Handling Resource",
-		"Type", *t.Resource.ResourceType)
-	if t.Resource.ResourceType == nil {
-		return fmt.Errorf("nil resource")
+	if opts.StopPolicy != nil {
+		p, err := configuration.ParseStopPolicy(*opts.StopPolicy)
+		if err != nil {
+			return nil, err
+		}
+		h.stopPolicy = p
+	} else {
+		h.stopPolicy = configuration.DEFAULT_STOP_POLICY
 	}
 
-	// Require token for rate limiting
-	ctx, cancel := context.WithTimeout(context.Background(), MAX_INTERVAL)
-	defer cancel()
-	h.tp.Acquire(ctx)
-
-	switch *t.Resource.ResourceType {
-	case "Instance":
-		return h.handleCompute(t)
-	case "DbSystem":
-		return h.handleDbSystem(t)
-	case "AnalyticsInstance":
-		return h.handleAnalyticsInstance(t)
+	if opts.StopGrace != nil {
+		h.stopGrace = *opts.StopGrace
+	} else {
+		h.stopGrace = configuration.DEFAULT_STOP_GRACE
 	}
 
-	return nil
-}
-
-// HandleCompute takes actions on compute resources. Limited to turning instance
-// on or off.
-func (h *ResourceHandler) handleCompute(t task.Task) error {
-	logGroup := getResourceGroup(t)
-
-	h.log.Debug("Handling Compute", logGroup)
-
-	// Turn off if action is off and instance is not already off
-	if t.Action == action.OFF && (*t.Resource.LifecycleState != "STOPPED" &&
-		*t.Resource.LifecycleState != "STOPPING" &&
-		*t.Resource.LifecycleState != "TERMINATING" &&
-		*t.Resource.LifecycleState != "TERMINATED") {
-		ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_INTERVAL)
-		defer cancel()
-
-		req := core.InstanceActionRequest{
-			InstanceId: t.Resource.Identifier,
-			Action:     core.InstanceActionActionStop,
-		}
-
-		resp, err := h.compute.InstanceAction(ctx, req)
+	if opts.MysqlShutdown != nil {
+		m, err := configuration.ParseMysqlShutdown(*opts.MysqlShutdown)
 		if err != nil {
-			return err
-		}
-		h.log.Info("Compute Handled",
-			slog.String("Action", "STOP"),
-			slog.String("Status Message", resp.RawResponse.Status),
-			logGroup)
-
-	} else if t.Action == action.ON && (*t.Resource.LifecycleState != "RUNNING" &&
-		*t.Resource.LifecycleState != "STARTING" &&
-		*t.Resource.LifecycleState != "TERMINATING" &&
-		*t.Resource.LifecycleState != "TERMINATED") {
-		// Else turn on -- no vertical scaling supported at this time
-		ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_INTERVAL)
-		defer cancel()
-
-		req := core.InstanceActionRequest{
-			InstanceId: t.Resource.Identifier,
-			Action:     core.InstanceActionActionStart,
+			return nil, err
 		}
+		h.mysqlShutdown = mysql.InnoDbShutdownModeEnum(m)
+	} else {
+		h.mysqlShutdown = mysql.InnoDbShutdownModeEnum(configuration.DEFAULT_MYSQL_SHUTDOWN)
+	}
 
-		resp, err := h.compute.InstanceAction(ctx, req)
+	if opts.ExadataPolicy != nil {
+		e, err := configuration.ParseExadataPolicy(*opts.ExadataPolicy)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		h.log.Info("Compute Handled",
-			slog.String("Action", "START"),
-			slog.String("Status Message", resp.RawResponse.Status),
-			logGroup)
+		h.exadataPolicy = e
 	} else {
-		h.log.Info("Compute Handled - No Action Required",
-			slog.String("State", *t.Resource.LifecycleState),
-			slog.String("Action", "NONE"), logGroup)
+		h.exadataPolicy = configuration.DEFAULT_EXADATA_POLICY
 	}
 
-	return nil
-}
+	if opts.ConfigProvider == nil {
+		return nil, fmt.Errorf("error Handler cannot have nil ConfigProvider")
+	}
+
+	h.configProvider = opts.ConfigProvider
+	h.services = make(map[string]serviceCache)
 
-// handleDbSystem starts or stops Database Node resources.
-func (h *ResourceHandler) handleDbSystem(t task.Task) error {
-	nodes := h.getDbNodes(t.Resource.Identifier)
+	return &h, nil
+}
 
-	// helper to safely dereference pointers for error messages
-	str := func(p *string) string {
-		if p == nil {
-			return "<nil>"
+// SearchTypes returns the search types of every registered resource type enabled
+// by the handler's configuration
+func (h *ResourceHandler) SearchTypes() []string {
+	types := make([]string, 0)
+	for _, r := range Registrations() {
+		if r.Enabled == nil || r.Enabled(h) {
+			types = append(types, r.SearchType)
 		}
-		return *p
 	}
 
-	var errs []error
+	return types
+}
 
-	for _, node := range nodes {
-		logGroup := getResourceGroup(task.NewTask(t.Action, node))
-		h.log.Debug("Handling DB Node", logGroup)
+// SetRegion sets the region of services used by subsequent calls. Services are
+// built the first time a resource type is handled in a region.
+func (h *ResourceHandler) SetRegion(region string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
-		// Turn DB Node Off
-		if t.Action == action.OFF && (*node.LifecycleState != "STOPPED" &&
-			*node.LifecycleState != "STOPPING" &&
-			*node.LifecycleState != "TERMINATING" &&
-			*node.LifecycleState != "TERMINATED") {
-			ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_INTERVAL)
+	h.region = region
+}
 
-			req := database.DbNodeActionRequest{
-				DbNodeId: node.Identifier,
-				Action:   database.DbNodeActionActionStop,
-			}
+// serviceCache holds the services built for a single region by resource type
+type serviceCache map[string]cachedService
 
-			resp, err := h.database.DbNodeAction(ctx, req)
-			cancel()
-			if err != nil {
-				errs = append(errs, fmt.Errorf(
-					"stop dbnode %s (dbSystem %s, state %s) failed: %w",
-					str(node.Identifier), str(t.Resource.Identifier), str(node.LifecycleState), err,
-				))
-				continue
-			}
+// cachedService is a built service or the error returned building it
+type cachedService struct {
+	svc Service
+	err error
+}
 
-			h.log.Info("Handled DB Node",
-				slog.String("Action", "STOP"),
-				slog.String("Status", resp.RawResponse.Status),
-				logGroup)
-		} else if t.Action == action.ON && (*node.LifecycleState != "RUNNING" &&
-			*node.LifecycleState != "STARTING" &&
-			*node.LifecycleState != "TERMINATING" &&
-			*node.LifecycleState != "TERMINATED") {
-			// Turn DB Node On
-			ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_INTERVAL)
-
-			req := database.DbNodeActionRequest{
-				DbNodeId: node.Identifier,
-				Action:   database.DbNodeActionActionStart,
-			}
+// service returns the service for a resource type in the current region,
+// building its clients on first use. Construction errors are cached so a
+// failing resource type is reported for each of its resources without
+// affecting other resource types. Returns false if the type is not registered.
+func (h *ResourceHandler) service(resourceType string) (Service, bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cache, ok := h.services[h.region]
+	if !ok {
+		cache = make(serviceCache)
+		h.services[h.region] = cache
+	}
 
-			resp, err := h.database.DbNodeAction(ctx, req)
-			cancel()
-			if err != nil {
-				errs = append(errs, fmt.Errorf(
-					"start dbnode %s (dbSystem %s, state %s) failed: %w",
-					str(node.Identifier), str(t.Resource.Identifier), str(node.LifecycleState), err,
-				))
-				continue
-			}
+	if c, ok := cache[resourceType]; ok {
+		return c.svc, true, c.err
+	}
 
-			h.log.Info("Handled DB Node",
-				slog.String("Action", "START"),
-				slog.String("Status", resp.RawResponse.Status),
-				logGroup)
-		} else {
-			h.log.Info("DB Node Handled - No Action Required",
-				slog.String("State", *t.Resource.LifecycleState),
-				slog.String("Action", "NONE"), logGroup)
-		}
+	r, ok := registration(resourceType)
+	if !ok {
+		return nil, false, nil
 	}
 
-	if len(errs) > 0 {
-		return fmt.Errorf(
-			"dbSystem %s: one or more DB node actions failed: %w",
-			str(t.Resource.Identifier), errors.Join(errs...),
-		)
+	h.log.Debug("Creating service clients",
+		slog.String("Service", r.Name),
+		slog.String("Region", h.region))
+
+	svc, err := r.New(h)
+	if err != nil {
+		err = fmt.Errorf("error creating %s clients in region %s: %w", r.Name,
+			h.region, err)
+		h.log.Error("Unable to create service clients",
+			slog.String("Service", r.Name),
+			"error", err)
+	} else if h.region != "" {
+		svc.SetRegion(h.region)
 	}
+	cache[resourceType] = cachedService{svc: svc, err: err}
 
-	return nil
+	return svc, true, err
 }
 
-// handleAnalyticsInstance activates/deactivates OAC instances
-func (h *ResourceHandler) handleAnalyticsInstance(t task.Task) error {
-	logGroup := getResourceGroup(t)
-
-	h.log.Debug("Handling Analytics Instance", logGroup)
+// HandleResource routes task to the service registered for its resource type,
+// starting or stopping the resource if its lifecycle state allows
+func (h *ResourceHandler) HandleResource(t task.Task) error {
+	if t.Resource.ResourceType == nil || t.Resource.Identifier == nil ||
+		t.Resource.LifecycleState == nil {
+		return fmt.Errorf("nil resource")
+	}
+	h.log.Debug("Handling Resource",
+		"Type", *t.Resource.ResourceType)
 
-	// Deactivate Analytics Instance
-	if t.Action == action.OFF && *t.Resource.LifecycleState != "Inactive" &&
-		*t.Resource.LifecycleState != "DELETED" {
-		ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_INTERVAL)
-		defer cancel()
+	rt := *t.Resource.ResourceType
+	svc, ok, err := h.service(rt)
+	if !ok {
+		h.log.Warn("No service registered for resource type",
+			slog.String("Type", rt))
+		return nil
+	} else if err != nil {
+		return err
+	}
+	name := registrationName(rt)
+	logGroup := getResourceGroup(t)
 
-		req := analytics.StopAnalyticsInstanceRequest{
-			AnalyticsInstanceId: t.Resource.Identifier,
-		}
+	if !action.Compare(svc.Actions(), t.Action) {
+		h.log.Info(name+" Handled - Action Not Supported",
+			slog.String("Action", "NONE"), logGroup)
+		return nil
+	}
 
-		resp, err := h.analytics.StopAnalyticsInstance(ctx, req)
-		if err != nil {
-			return err
+	state := *t.Resource.LifecycleState
+	if actionable(svc, t) {
+		if sk, ok := svc.(Skipper); ok {
+			if err := sk.Skip(t); err != nil {
+				return err
+			}
 		}
 
-		h.log.Info("Stopped Analytics Instance",
-			slog.String("Action", "STOP"),
-			slog.String("Status", resp.RawResponse.Status),
-			logGroup)
-	} else if t.Action == action.ON && *t.Resource.LifecycleState != "RUNNING" &&
-		*t.Resource.LifecycleState != "DELETED" {
-		ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_INTERVAL)
+		// Require token for rate limiting
+		ctx, cancel := context.WithTimeout(context.Background(), MAX_INTERVAL)
 		defer cancel()
+		h.tp.Acquire(ctx)
 
-		req := analytics.StartAnalyticsInstanceRequest{
-			AnalyticsInstanceId: t.Resource.Identifier,
+		if t.Action == action.OFF {
+			return svc.Stop(t)
 		}
+		return svc.Start(t)
+	}
 
-		resp, err := h.analytics.StartAnalyticsInstance(ctx, req)
-		if err != nil {
-			return err
-		}
+	h.log.Info(name+" Handled - No Action Required",
+		slog.String("State", state),
+		slog.String("Action", "NONE"), logGroup)
 
-		h.log.Info("Started Analytics Instance",
-			slog.String("Action", "START"),
-			slog.String("Status", resp.RawResponse.Status),
-			logGroup)
-	} else {
-		h.log.Info("Analytics Instance Handled - No Action Required",
-			slog.String("Action", "NONE"),
-			slog.String("State", *t.Resource.LifecycleState),
-			logGroup)
+	// Prerequisites already on their way are waited on so dependents are only
+	// handled once they arrive
+	if w, ok := svc.(Waiter); ok && t.Wait && transitioning(t.Action, state) {
+		return w.WaitFor(t)
 	}
 
 	return nil
 }
 
-func getResourceGroup(t task.Task) slog.Attr {
-	return slog.Group("Resource",
-		slog.String("ID", *t.Resource.Identifier),
-		slog.String("Type", *t.Resource.ResourceType),
-		slog.Int("Action", int(t.Action)),
-		slog.String("State", *t.Resource.LifecycleState),
-	)
+// transitioning returns true if a resource in the lifecycle state is moving
+// toward the target state of the action
+func transitioning(act action.Action, state string) bool {
+	state = strings.ToUpper(state)
+	return (act == action.ON && state == "STARTING") ||
+		(act == action.OFF && state == "STOPPING")
 }
 
-func (h *ResourceHandler) getDbNodes(id *string) []rs.ResourceSummary {
-	nodes := make([]rs.ResourceSummary, 0)
+// Actionable returns true if the service registered for the task's resource type
+// starts or stops the resource in its current lifecycle state and does not skip it.
+// Resources whose service cannot tell whether they need an action are counted.
+func (h *ResourceHandler) Actionable(t task.Task) bool {
+	if t.Resource.ResourceType == nil || t.Resource.LifecycleState == nil {
+		return false
+	}
 
-	query := fmt.Sprintf(
-		"query dbnode resources return alladditionalfields where dbSystemId = '%s'",
-		*id)
+	svc, ok, err := h.service(*t.Resource.ResourceType)
+	if !ok || err != nil {
+		return false
+	}
 
-	h.log.Debug("Searching for DbSystem Nodes",
-		slog.String("Query", query),
-		slog.String("DbSystem ID", *id))
+	if !action.Compare(svc.Actions(), t.Action) || !actionable(svc, t) {
+		return false
+	}
 
-	details := rs.StructuredSearchDetails{
-		Query: common.String(query),
+	if sk, ok := svc.(Skipper); ok && sk.Skip(t) != nil {
+		return false
 	}
 
-	req := rs.SearchResourcesRequest{
-		SearchDetails: details,
+	if c, ok := svc.(Checker); ok {
+		needs, err := c.NeedsAction(t)
+		if err != nil {
+			h.log.Warn("Unable to check if resource needs action",
+				slog.String("Error", err.Error()), getResourceGroup(t))
+			return true
+		}
+		return needs
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_INTERVAL)
-	defer cancel()
-	resp, err := h.search.SearchResources(ctx, req)
-	if err != nil {
-		h.log.Error("Error searching for DB Nodes",
-			"error", err)
-		return nodes
-	} else if resp.RawResponse.StatusCode > 299 || resp.RawResponse.StatusCode < 200 {
-		h.log.Error("Error searching for DB nodes: invalid status in response",
-			slog.Int("code", resp.RawResponse.StatusCode))
+	return true
+}
+
+// actionable returns true if svc acts on the task in its resource's state
+func actionable(svc Service, t task.Task) bool {
+	state := *t.Resource.LifecycleState
+	return (t.Action == action.OFF && svc.CanStop(state)) ||
+		(t.Action == action.ON && svc.CanStart(state))
+}
+
+// computeStopPolicy returns the stop policy set on the resource's StopPolicy tag,
+// falling back to the handler default if unset or invalid
+func (h *ResourceHandler) computeStopPolicy(t task.Task) string {
+	v, ok := t.Resource.DefinedTags[h.tagNamespace][STOP_POLICY_KEY]
+	if !ok || v == nil {
+		return h.stopPolicy
 	}
 
-	for _, item := range resp.Items {
-		h.log.Debug("appending item",
-			slog.String("DB System", *id),
-			slog.String("Node", *item.Identifier))
-		nodes = append(nodes, item)
+	policy, err := configuration.ParseStopPolicy(fmt.Sprint(v))
+	if err != nil {
+		h.log.Warn("Ignoring invalid stop policy tag",
+			slog.String("Default", h.stopPolicy),
+			"error", err,
+			getResourceGroup(t))
+		return h.stopPolicy
 	}
 
-	h.log.Debug("Returning search for DB Nodes",
-		slog.String("DB System", *id),
-		slog.Int("Count", len(nodes)))
+	return policy
+}
 
-	return nodes
+func getResourceGroup(t task.Task) slog.Attr {
+	return slog.Group("Resource",
+		slog.String("ID", *t.Resource.Identifier),
+		slog.String("Type", *t.Resource.ResourceType),
+		slog.Int("Action", int(t.Action)),
+		slog.String("State", *t.Resource.LifecycleState),
+	)
 }
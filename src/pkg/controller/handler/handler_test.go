@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/database"
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+var errTestClient error = errors.New("test client unavailable")
+
+func TestHandleResource_ClientErrorPerType(t *testing.T) {
+	registerTestService(t, Registration{
+		Name:         "Broken Test Service",
+		SearchType:   "brokentestservice",
+		ResourceType: "BrokenTestService",
+		New: func(*ResourceHandler) (Service, error) {
+			return nil, errTestClient
+		},
+	})
+
+	h := &ResourceHandler{
+		log:      slog.Default(),
+		services: make(map[string]serviceCache),
+	}
+	h.SetRegion("us-ashburn-1")
+
+	broken := task.NewTask(action.OFF, rs.ResourceSummary{
+		Identifier:     common.String("ocid1.test.oc1..aaaa"),
+		ResourceType:   common.String("BrokenTestService"),
+		LifecycleState: common.String("ACTIVE"),
+	})
+
+	for range 2 {
+		if err := h.HandleResource(broken); !errors.Is(err, errTestClient) {
+			t.Fatalf("expected client error, got %v", err)
+		}
+	}
+
+	// Unregistered types are skipped without building anything
+	unknown := testTask(action.OFF, "ACTIVE")
+	if err := h.HandleResource(unknown); err != nil {
+		t.Fatalf("unexpected error for unregistered type: %v", err)
+	}
+
+	if n := len(h.services["us-ashburn-1"]); n != 1 {
+		t.Fatalf("expected 1 cached service, got %d", n)
+	}
+}
+
+func TestHandleResource_AutonomousDatabaseSearchOnly(t *testing.T) {
+	h := &ResourceHandler{
+		log:      slog.Default(),
+		services: make(map[string]serviceCache),
+	}
+
+	for _, act := range []action.Action{action.ON, action.OFF} {
+		adb := task.NewTask(act, rs.ResourceSummary{
+			Identifier:     common.String("ocid1.autonomousdatabase.oc1..aaaa"),
+			ResourceType:   common.String("AutonomousDatabase"),
+			LifecycleState: common.String("AVAILABLE"),
+		})
+
+		if h.Actionable(adb) {
+			t.Fatalf("expected autonomous database not to be actionable")
+		}
+		if err := h.HandleResource(adb); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestActionable_AlreadyStopped(t *testing.T) {
+	h := &ResourceHandler{
+		log:         slog.Default(),
+		retryPolicy: DefaultRetryPolicy(),
+		services:    make(map[string]serviceCache),
+	}
+
+	db := newFakeDatabaseClient("n1", "n2")
+	pool := &fakeContainerEngineClient{size: 0}
+	h.services[""] = serviceCache{
+		"DbSystem": {svc: &dbSystemService{dbNodeHandler{h: h, database: db}}},
+		"NodePool": {svc: &nodePoolService{h: h, client: pool}},
+	}
+
+	dbSystem := dbSystemTask(action.OFF, "VM.Standard.E4.Flex")
+	if !h.Actionable(dbSystem) {
+		t.Fatalf("expected DB system with available nodes to be actionable")
+	}
+	for i := range db.pages[0] {
+		db.pages[0][i].LifecycleState = database.DbNodeSummaryLifecycleStateStopped
+	}
+	if h.Actionable(dbSystem) {
+		t.Fatalf("expected DB system with stopped nodes not to be actionable")
+	}
+
+	if h.Actionable(nodePoolTask(action.OFF)) {
+		t.Fatalf("expected node pool of size 0 not to be actionable")
+	}
+	pool.size = 2
+	if !h.Actionable(nodePoolTask(action.OFF)) {
+		t.Fatalf("expected node pool of size 2 to be actionable")
+	}
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/configuration"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+func init() {
+	Register(Registration{
+		Name:         "Instance Pool",
+		SearchType:   "instancepool",
+		ResourceType: "InstancePool",
+		New:          newInstancePoolService,
+	})
+}
+
+// computeManagementClient is the part of core.ComputeManagementClient used to act
+// on instance pools and find their members
+type computeManagementClient interface {
+	SetRegion(string)
+	StartInstancePool(context.Context, core.StartInstancePoolRequest) (core.StartInstancePoolResponse, error)
+	StopInstancePool(context.Context, core.StopInstancePoolRequest) (core.StopInstancePoolResponse, error)
+	SoftstopInstancePool(context.Context, core.SoftstopInstancePoolRequest) (core.SoftstopInstancePoolResponse, error)
+	GetInstancePool(context.Context, core.GetInstancePoolRequest) (core.GetInstancePoolResponse, error)
+	ListInstancePools(context.Context, core.ListInstancePoolsRequest) (core.ListInstancePoolsResponse, error)
+	ListInstancePoolInstances(context.Context, core.ListInstancePoolInstancesRequest) (core.ListInstancePoolInstancesResponse, error)
+}
+
+// instancePoolService starts or stops all instances in a pool using pool level
+// actions so that the pool does not replace stopped members. Stop follows the
+// same stop policy as standalone compute instances.
+type instancePoolService struct {
+	h      *ResourceHandler
+	client computeManagementClient
+}
+
+func newInstancePoolService(h *ResourceHandler) (Service, error) {
+	c, err := core.NewComputeManagementClientWithConfigurationProvider(h.configProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	return &instancePoolService{h: h, client: &c}, nil
+}
+
+func (s *instancePoolService) Actions() action.Action {
+	return action.ON | action.OFF
+}
+
+func (s *instancePoolService) SetRegion(region string) {
+	s.client.SetRegion(region)
+}
+
+func (s *instancePoolService) CanStop(state string) bool {
+	return state != "STOPPED" &&
+		state != "STOPPING" &&
+		state != "TERMINATING" &&
+		state != "TERMINATED"
+}
+
+func (s *instancePoolService) CanStart(state string) bool {
+	return state != "RUNNING" &&
+		state != "STARTING" &&
+		state != "TERMINATING" &&
+		state != "TERMINATED"
+}
+
+func (s *instancePoolService) Stop(t task.Task) error {
+	logGroup := getResourceGroup(t)
+	policy := s.h.computeStopPolicy(t)
+
+	var resp *http.Response
+	err := s.h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+		if policy == configuration.STOP_HARD {
+			r, err := s.client.StopInstancePool(ctx,
+				core.StopInstancePoolRequest{InstancePoolId: t.Resource.Identifier})
+			resp = r.RawResponse
+			return resp, err
+		}
+
+		r, err := s.client.SoftstopInstancePool(ctx,
+			core.SoftstopInstancePoolRequest{InstancePoolId: t.Resource.Identifier})
+		resp = r.RawResponse
+		return resp, err
+	})
+	if err != nil {
+		return err
+	}
+	s.h.log.Info("Instance Pool Handled",
+		slog.String("Action", "STOP"),
+		slog.String("Stop Policy", policy),
+		slog.String("Status Message", resp.Status),
+		logGroup)
+
+	if policy == configuration.STOP_GRACEFUL {
+		return s.h.escalate(t, s.escalateStop)
+	}
+
+	return s.h.confirm(t, s.instancePoolState(t.Resource.Identifier),
+		string(core.InstancePoolLifecycleStateStopped))
+}
+
+func (s *instancePoolService) Start(t task.Task) error {
+	logGroup := getResourceGroup(t)
+
+	var resp core.StartInstancePoolResponse
+	err := s.h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+		var err error
+		resp, err = s.client.StartInstancePool(ctx,
+			core.StartInstancePoolRequest{InstancePoolId: t.Resource.Identifier})
+		return resp.RawResponse, err
+	})
+	if err != nil {
+		return err
+	}
+	s.h.log.Info("Instance Pool Handled",
+		slog.String("Action", "START"),
+		slog.String("Status Message", resp.RawResponse.Status),
+		logGroup)
+
+	return s.h.confirm(t, s.instancePoolState(t.Resource.Identifier),
+		string(core.InstancePoolLifecycleStateRunning))
+}
+
+// WaitFor waits for a starting or stopping pool to reach its target state
+func (s *instancePoolService) WaitFor(t task.Task) error {
+	target := core.InstancePoolLifecycleStateStopped
+	if t.Action == action.ON {
+		target = core.InstancePoolLifecycleStateRunning
+	}
+
+	return s.h.confirm(t, s.instancePoolState(t.Resource.Identifier), string(target))
+}
+
+// escalateStop hard stops a soft stopped pool that has not reached STOPPED
+// within the grace period
+func (s *instancePoolService) escalateStop(t task.Task) error {
+	logGroup := getResourceGroup(t)
+	id := t.Resource.Identifier
+
+	err := s.h.waitForState(logGroup, s.h.stopGrace, s.instancePoolState(id),
+		string(core.InstancePoolLifecycleStateStopped))
+	if !errors.Is(err, ErrWaitTimeout) {
+		return err
+	}
+
+	s.h.log.Warn("Instance pool did not stop within grace period, escalating to hard stop",
+		slog.Duration("Grace Period", s.h.stopGrace),
+		logGroup)
+
+	var resp core.StopInstancePoolResponse
+	err = s.h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+		var err error
+		resp, err = s.client.StopInstancePool(ctx,
+			core.StopInstancePoolRequest{InstancePoolId: id})
+		return resp.RawResponse, err
+	})
+	if err != nil {
+		return fmt.Errorf("escalate to hard stop: %w", err)
+	}
+	s.h.log.Info("Instance Pool Handled",
+		slog.String("Action", "STOP"),
+		slog.String("Status Message", resp.RawResponse.Status),
+		logGroup)
+
+	return s.h.confirm(t, s.instancePoolState(id),
+		string(core.InstancePoolLifecycleStateStopped))
+}
+
+// instancePoolState returns a getter for the lifecycle state of an instance pool
+func (s *instancePoolService) instancePoolState(id *string) stateGetter {
+	return func(ctx context.Context) (string, *http.Response, error) {
+		resp, err := s.client.GetInstancePool(ctx,
+			core.GetInstancePoolRequest{InstancePoolId: id})
+		return string(resp.LifecycleState), resp.RawResponse, err
+	}
+}
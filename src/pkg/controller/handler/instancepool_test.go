@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/configuration"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	tokenpool "github.com/flynnkc/token-pool"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+// fakeComputeManagementClient records pool actions and serves one page of pools
+// per call, each with a page of instances
+type fakeComputeManagementClient struct {
+	starts, stops, softstops int
+	pools                    []core.InstancePoolSummary
+	instances                map[string][]string // Instance OCIDs by pool OCID
+	lists                    int
+}
+
+func (c *fakeComputeManagementClient) SetRegion(string) {}
+
+func (c *fakeComputeManagementClient) StartInstancePool(context.Context,
+	core.StartInstancePoolRequest) (core.StartInstancePoolResponse, error) {
+	c.starts++
+	return core.StartInstancePoolResponse{RawResponse: okResponse()}, nil
+}
+
+func (c *fakeComputeManagementClient) StopInstancePool(context.Context,
+	core.StopInstancePoolRequest) (core.StopInstancePoolResponse, error) {
+	c.stops++
+	return core.StopInstancePoolResponse{RawResponse: okResponse()}, nil
+}
+
+func (c *fakeComputeManagementClient) SoftstopInstancePool(context.Context,
+	core.SoftstopInstancePoolRequest) (core.SoftstopInstancePoolResponse, error) {
+	c.softstops++
+	return core.SoftstopInstancePoolResponse{RawResponse: okResponse()}, nil
+}
+
+func (c *fakeComputeManagementClient) GetInstancePool(context.Context,
+	core.GetInstancePoolRequest) (core.GetInstancePoolResponse, error) {
+	return core.GetInstancePoolResponse{RawResponse: okResponse()}, nil
+}
+
+func (c *fakeComputeManagementClient) ListInstancePools(_ context.Context,
+	req core.ListInstancePoolsRequest) (core.ListInstancePoolsResponse, error) {
+	c.lists++
+
+	// One pool per page
+	i := 0
+	if req.Page != nil {
+		i = int((*req.Page)[0] - '0')
+	}
+	resp := core.ListInstancePoolsResponse{RawResponse: okResponse()}
+	if i < len(c.pools) {
+		resp.Items = c.pools[i : i+1]
+	}
+	if i+1 < len(c.pools) {
+		resp.OpcNextPage = common.String(string(rune('0' + i + 1)))
+	}
+
+	return resp, nil
+}
+
+func (c *fakeComputeManagementClient) ListInstancePoolInstances(_ context.Context,
+	req core.ListInstancePoolInstancesRequest) (core.ListInstancePoolInstancesResponse, error) {
+	resp := core.ListInstancePoolInstancesResponse{RawResponse: okResponse()}
+	for _, id := range c.instances[*req.InstancePoolId] {
+		resp.Items = append(resp.Items, core.InstanceSummary{Id: common.String(id)})
+	}
+
+	return resp, nil
+}
+
+func testPoolHandler() *ResourceHandler {
+	return &ResourceHandler{
+		log:         slog.Default(),
+		retryPolicy: DefaultRetryPolicy(),
+		tp:          tokenpool.NewTokenPool(8, 8, time.Hour),
+		services:    make(map[string]serviceCache),
+		stopPolicy:  configuration.STOP_HARD,
+		stopGrace:   configuration.DEFAULT_STOP_GRACE,
+	}
+}
+
+func poolTask(act action.Action, state string, tags map[string]interface{}) task.Task {
+	return task.NewTask(act, rs.ResourceSummary{
+		Identifier:     common.String("ocid1.instancepool.oc1..pool"),
+		ResourceType:   common.String("InstancePool"),
+		CompartmentId:  common.String("ocid1.compartment.oc1..dev"),
+		LifecycleState: common.String(state),
+		DefinedTags:    map[string]map[string]interface{}{"Schedule": tags},
+	})
+}
+
+func TestInstancePool_Actions(t *testing.T) {
+	cases := []struct {
+		name                     string
+		act                      action.Action
+		state                    string
+		tags                     map[string]interface{}
+		starts, stops, softstops int
+	}{
+		{"start", action.ON, "STOPPED", nil, 1, 0, 0},
+		{"start running", action.ON, "RUNNING", nil, 0, 0, 0},
+		{"hard stop", action.OFF, "RUNNING", nil, 0, 1, 0},
+		{"soft stop tag", action.OFF, "RUNNING",
+			map[string]interface{}{STOP_POLICY_KEY: "soft"}, 0, 0, 1},
+		{"stop stopped", action.OFF, "STOPPED", nil, 0, 0, 0},
+	}
+
+	for _, c := range cases {
+		h := testPoolHandler()
+		h.tagNamespace = "Schedule"
+		client := &fakeComputeManagementClient{}
+		h.services[""] = serviceCache{
+			"InstancePool": {svc: &instancePoolService{h: h, client: client}},
+		}
+
+		if err := h.HandleResource(poolTask(c.act, c.state, c.tags)); err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.name, err)
+		}
+		if client.starts != c.starts || client.stops != c.stops ||
+			client.softstops != c.softstops {
+			t.Errorf("%s: got %d starts, %d stops, %d softstops", c.name,
+				client.starts, client.stops, client.softstops)
+		}
+	}
+}
+
+func TestCompute_SkipsPoolMembers(t *testing.T) {
+	h := testPoolHandler()
+	client := &fakeComputeManagementClient{
+		pools: []core.InstancePoolSummary{
+			{Id: common.String("ocid1.instancepool.oc1..a")},
+			{Id: common.String("ocid1.instancepool.oc1..b")},
+			{Id: common.String("ocid1.instancepool.oc1..gone"),
+				LifecycleState: core.InstancePoolSummaryLifecycleStateTerminated},
+		},
+		instances: map[string][]string{
+			"ocid1.instancepool.oc1..a":    {"ocid1.instance.oc1..a1"},
+			"ocid1.instancepool.oc1..b":    {"ocid1.instance.oc1..b1"},
+			"ocid1.instancepool.oc1..gone": {"ocid1.instance.oc1..standalone"},
+		},
+	}
+	svc := &computeService{h: h, pools: client,
+		members: make(map[string]map[string]string)}
+	h.services[""] = serviceCache{"Instance": {svc: svc}}
+
+	instance := func(id string) task.Task {
+		return task.NewTask(action.OFF, rs.ResourceSummary{
+			Identifier:     common.String(id),
+			ResourceType:   common.String("Instance"),
+			CompartmentId:  common.String("ocid1.compartment.oc1..dev"),
+			LifecycleState: common.String("RUNNING"),
+		})
+	}
+
+	tokens := h.tp.NumTokens()
+	for _, id := range []string{"ocid1.instance.oc1..a1", "ocid1.instance.oc1..b1"} {
+		err := h.HandleResource(instance(id))
+		if !errors.As(err, &ErrSkipped{}) {
+			t.Fatalf("%s: expected ErrSkipped, got %v", id, err)
+		}
+	}
+	if n := h.tp.NumTokens(); n != tokens {
+		t.Fatalf("expected skipped instances not to take tokens, %d of %d left", n, tokens)
+	}
+
+	if h.Actionable(instance("ocid1.instance.oc1..a1")) {
+		t.Fatalf("expected pool member not to be actionable")
+	}
+	if err := svc.Skip(instance("ocid1.instance.oc1..standalone")); err != nil {
+		t.Fatalf("expected standalone instance not to be skipped, got %v", err)
+	}
+	if client.lists != 3 {
+		t.Fatalf("expected pools listed once across 3 pages, got %d calls", client.lists)
+	}
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/integration"
+)
+
+// Integration instances are started and stopped like other PaaS services. They
+// were previously found by search without being acted on.
+func init() {
+	RegisterPaaS(PaaSService{
+		Name:          "Integration Instance",
+		SearchType:    "integrationinstance",
+		ResourceType:  "IntegrationInstance",
+		RunningStates: []string{string(integration.IntegrationInstanceLifecycleStateActive)},
+		StoppedStates: []string{string(integration.IntegrationInstanceLifecycleStateInactive)},
+		NewClient: func(cp common.ConfigurationProvider) (PaaSClient, error) {
+			c, err := integration.NewIntegrationInstanceClientWithConfigurationProvider(cp)
+			return &integrationClient{c}, err
+		},
+	})
+}
+
+type integrationClient struct {
+	integration.IntegrationInstanceClient
+}
+
+func (c *integrationClient) Start(ctx context.Context, id *string) (*http.Response, error) {
+	resp, err := c.StartIntegrationInstance(ctx,
+		integration.StartIntegrationInstanceRequest{IntegrationInstanceId: id})
+	return resp.RawResponse, err
+}
+
+func (c *integrationClient) Stop(ctx context.Context, id *string) (*http.Response, error) {
+	resp, err := c.StopIntegrationInstance(ctx,
+		integration.StopIntegrationInstanceRequest{IntegrationInstanceId: id})
+	return resp.RawResponse, err
+}
+
+func (c *integrationClient) State(ctx context.Context, id *string) (string, *http.Response, error) {
+	resp, err := c.GetIntegrationInstance(ctx,
+		integration.GetIntegrationInstanceRequest{IntegrationInstanceId: id})
+	return string(resp.LifecycleState), resp.RawResponse, err
+}
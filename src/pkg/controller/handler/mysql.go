@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/mysql"
+)
+
+func init() {
+	Register(Registration{
+		Name:         "MySQL DB System",
+		SearchType:   "mysqldbsystem",
+		ResourceType: "MysqlDbSystem",
+		New:          newMysqlService,
+	})
+}
+
+// mysqlService starts or stops MySQL DB systems. An attached HeatWave cluster is
+// stopped before its DB system and started once the DB system is active again.
+type mysqlService struct {
+	h      *ResourceHandler
+	client mysqlClient
+}
+
+// mysqlClient is the part of mysql.DbSystemClient used to act on DB systems and
+// their HeatWave clusters
+type mysqlClient interface {
+	SetRegion(string)
+	GetDbSystem(context.Context, mysql.GetDbSystemRequest) (mysql.GetDbSystemResponse, error)
+	StartDbSystem(context.Context, mysql.StartDbSystemRequest) (mysql.StartDbSystemResponse, error)
+	StopDbSystem(context.Context, mysql.StopDbSystemRequest) (mysql.StopDbSystemResponse, error)
+	GetHeatWaveCluster(context.Context, mysql.GetHeatWaveClusterRequest) (mysql.GetHeatWaveClusterResponse, error)
+	StartHeatWaveCluster(context.Context, mysql.StartHeatWaveClusterRequest) (mysql.StartHeatWaveClusterResponse, error)
+	StopHeatWaveCluster(context.Context, mysql.StopHeatWaveClusterRequest) (mysql.StopHeatWaveClusterResponse, error)
+}
+
+func newMysqlService(h *ResourceHandler) (Service, error) {
+	c, err := mysql.NewDbSystemClientWithConfigurationProvider(h.configProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mysqlService{h: h, client: &c}, nil
+}
+
+func (s *mysqlService) Actions() action.Action {
+	return action.ON | action.OFF
+}
+
+func (s *mysqlService) SetRegion(region string) {
+	s.client.SetRegion(region)
+}
+
+// Unlike compute, only settled DB systems are acted on. The MySQL API rejects
+// starting or stopping a DB system that is not ACTIVE or INACTIVE, as in the
+// allow-lists of PaaS services, so transitional and failed states are left for a
+// later run rather than failing.
+func (s *mysqlService) CanStop(state string) bool {
+	return state == string(mysql.DbSystemLifecycleStateActive)
+}
+
+func (s *mysqlService) CanStart(state string) bool {
+	return state == string(mysql.DbSystemLifecycleStateInactive)
+}
+
+func (s *mysqlService) Stop(t task.Task) error {
+	logGroup := getResourceGroup(t)
+
+	if err := s.stopHeatWave(logGroup, t.Resource.Identifier); err != nil {
+		return err
+	}
+
+	req := mysql.StopDbSystemRequest{
+		DbSystemId: t.Resource.Identifier,
+		StopDbSystemDetails: mysql.StopDbSystemDetails{
+			ShutdownType: s.h.mysqlShutdown,
+		},
+	}
+
+	var resp mysql.StopDbSystemResponse
+	err := s.h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+		var err error
+		resp, err = s.client.StopDbSystem(ctx, req)
+		return resp.RawResponse, err
+	})
+	if err != nil {
+		return err
+	}
+	s.h.log.Info("MySQL DB System Handled",
+		slog.String("Action", "STOP"),
+		slog.String("Shutdown Type", string(s.h.mysqlShutdown)),
+		slog.String("Status Message", resp.RawResponse.Status),
+		logGroup)
+
+	return s.h.confirm(t, s.mysqlState(t.Resource.Identifier),
+		string(mysql.DbSystemLifecycleStateInactive))
+}
+
+func (s *mysqlService) Start(t task.Task) error {
+	logGroup := getResourceGroup(t)
+
+	req := mysql.StartDbSystemRequest{DbSystemId: t.Resource.Identifier}
+
+	var resp mysql.StartDbSystemResponse
+	err := s.h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+		var err error
+		resp, err = s.client.StartDbSystem(ctx, req)
+		return resp.RawResponse, err
+	})
+	if err != nil {
+		return err
+	}
+	s.h.log.Info("MySQL DB System Handled",
+		slog.String("Action", "START"),
+		slog.String("Status Message", resp.RawResponse.Status),
+		logGroup)
+
+	return s.startHeatWave(t)
+}
+
+// heatWave returns the HeatWave cluster attached to a DB system or nil if none
+func (s *mysqlService) heatWave(logGroup slog.Attr,
+	id *string) (*mysql.HeatWaveClusterSummary, error) {
+	var resp mysql.GetDbSystemResponse
+	err := s.h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+		var err error
+		resp, err = s.client.GetDbSystem(ctx, mysql.GetDbSystemRequest{DbSystemId: id})
+		return resp.RawResponse, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.IsHeatWaveClusterAttached == nil || !*resp.IsHeatWaveClusterAttached {
+		return nil, nil
+	}
+
+	return resp.HeatWaveCluster, nil
+}
+
+// stopHeatWave stops an active HeatWave cluster and waits for it to become
+// inactive so that the DB system can be stopped
+func (s *mysqlService) stopHeatWave(logGroup slog.Attr, id *string) error {
+	hw, err := s.heatWave(logGroup, id)
+	if err != nil {
+		return err
+	}
+	if hw == nil || hw.LifecycleState != mysql.HeatWaveClusterLifecycleStateActive {
+		return nil
+	}
+
+	var resp mysql.StopHeatWaveClusterResponse
+	err = s.h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+		var err error
+		resp, err = s.client.StopHeatWaveCluster(ctx,
+			mysql.StopHeatWaveClusterRequest{DbSystemId: id})
+		return resp.RawResponse, err
+	})
+	if err != nil {
+		return fmt.Errorf("stop heatwave cluster: %w", err)
+	}
+	s.h.log.Info("HeatWave Cluster Handled",
+		slog.String("Action", "STOP"),
+		slog.String("Status Message", resp.RawResponse.Status),
+		logGroup)
+
+	return s.h.waitForState(logGroup, s.h.waitTimeout, s.heatWaveState(id),
+		string(mysql.HeatWaveClusterLifecycleStateInactive))
+}
+
+// startHeatWave waits for the DB system to become active and starts its
+// HeatWave cluster if one is attached
+func (s *mysqlService) startHeatWave(t task.Task) error {
+	logGroup := getResourceGroup(t)
+	id := t.Resource.Identifier
+
+	hw, err := s.heatWave(logGroup, id)
+	if err != nil {
+		return err
+	}
+	if hw == nil || hw.LifecycleState != mysql.HeatWaveClusterLifecycleStateInactive {
+		return s.h.confirm(t, s.mysqlState(id),
+			string(mysql.DbSystemLifecycleStateActive))
+	}
+
+	// HeatWave cannot be started until the DB system is active
+	err = s.h.waitForState(logGroup, s.h.waitTimeout, s.mysqlState(id),
+		string(mysql.DbSystemLifecycleStateActive))
+	if err != nil {
+		return err
+	}
+
+	var resp mysql.StartHeatWaveClusterResponse
+	err = s.h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+		var err error
+		resp, err = s.client.StartHeatWaveCluster(ctx,
+			mysql.StartHeatWaveClusterRequest{DbSystemId: id})
+		return resp.RawResponse, err
+	})
+	if err != nil {
+		return fmt.Errorf("start heatwave cluster: %w", err)
+	}
+	s.h.log.Info("HeatWave Cluster Handled",
+		slog.String("Action", "START"),
+		slog.String("Status Message", resp.RawResponse.Status),
+		logGroup)
+
+	return s.h.confirm(t, s.heatWaveState(id),
+		string(mysql.HeatWaveClusterLifecycleStateActive))
+}
+
+// mysqlState returns a getter for the lifecycle state of a MySQL DB system
+func (s *mysqlService) mysqlState(id *string) stateGetter {
+	return func(ctx context.Context) (string, *http.Response, error) {
+		resp, err := s.client.GetDbSystem(ctx, mysql.GetDbSystemRequest{DbSystemId: id})
+		return string(resp.LifecycleState), resp.RawResponse, err
+	}
+}
+
+// heatWaveState returns a getter for the lifecycle state of a HeatWave cluster
+func (s *mysqlService) heatWaveState(id *string) stateGetter {
+	return func(ctx context.Context) (string, *http.Response, error) {
+		resp, err := s.client.GetHeatWaveCluster(ctx,
+			mysql.GetHeatWaveClusterRequest{DbSystemId: id})
+		return string(resp.LifecycleState), resp.RawResponse, err
+	}
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/mysql"
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+// fakeMysqlClient serves a DB system and its HeatWave cluster, recording calls
+// in order. Each reports UPDATING for a number of gets after an action before
+// settling in its target state.
+type fakeMysqlClient struct {
+	db, hw           string // Current states, hw empty if no cluster is attached
+	dbPolls, hwPolls int    // Gets reporting UPDATING after an action
+	dbNext, hwNext   string // States entered once settled
+	events           []string
+}
+
+func (c *fakeMysqlClient) SetRegion(string) {}
+
+func (c *fakeMysqlClient) GetDbSystem(context.Context,
+	mysql.GetDbSystemRequest) (mysql.GetDbSystemResponse, error) {
+	state := settle(&c.db, &c.dbPolls, c.dbNext)
+	c.events = append(c.events, "get dbsystem "+state)
+
+	resp := mysql.GetDbSystemResponse{RawResponse: okResponse()}
+	resp.LifecycleState = mysql.DbSystemLifecycleStateEnum(state)
+	if c.hw != "" {
+		resp.IsHeatWaveClusterAttached = common.Bool(true)
+		resp.HeatWaveCluster = &mysql.HeatWaveClusterSummary{
+			LifecycleState: mysql.HeatWaveClusterLifecycleStateEnum(c.hw),
+		}
+	}
+
+	return resp, nil
+}
+
+func (c *fakeMysqlClient) StartDbSystem(context.Context,
+	mysql.StartDbSystemRequest) (mysql.StartDbSystemResponse, error) {
+	c.events = append(c.events, "start dbsystem")
+	c.dbNext = "ACTIVE"
+	return mysql.StartDbSystemResponse{RawResponse: okResponse()}, nil
+}
+
+func (c *fakeMysqlClient) StopDbSystem(context.Context,
+	mysql.StopDbSystemRequest) (mysql.StopDbSystemResponse, error) {
+	c.events = append(c.events, "stop dbsystem")
+	c.dbNext = "INACTIVE"
+	return mysql.StopDbSystemResponse{RawResponse: okResponse()}, nil
+}
+
+func (c *fakeMysqlClient) GetHeatWaveCluster(context.Context,
+	mysql.GetHeatWaveClusterRequest) (mysql.GetHeatWaveClusterResponse, error) {
+	state := settle(&c.hw, &c.hwPolls, c.hwNext)
+	c.events = append(c.events, "get heatwave "+state)
+
+	resp := mysql.GetHeatWaveClusterResponse{RawResponse: okResponse()}
+	resp.LifecycleState = mysql.HeatWaveClusterLifecycleStateEnum(state)
+	return resp, nil
+}
+
+func (c *fakeMysqlClient) StartHeatWaveCluster(context.Context,
+	mysql.StartHeatWaveClusterRequest) (mysql.StartHeatWaveClusterResponse, error) {
+	c.events = append(c.events, "start heatwave")
+	c.hwNext = "ACTIVE"
+	return mysql.StartHeatWaveClusterResponse{RawResponse: okResponse()}, nil
+}
+
+func (c *fakeMysqlClient) StopHeatWaveCluster(context.Context,
+	mysql.StopHeatWaveClusterRequest) (mysql.StopHeatWaveClusterResponse, error) {
+	c.events = append(c.events, "stop heatwave")
+	c.hwNext = "INACTIVE"
+	return mysql.StopHeatWaveClusterResponse{RawResponse: okResponse()}, nil
+}
+
+// settle returns UPDATING while polls remain after an action, then moves state
+// to next
+func settle(state *string, polls *int, next string) string {
+	if next == "" {
+		return *state
+	}
+	if *polls > 0 {
+		*polls--
+		return "UPDATING"
+	}
+
+	*state = next
+	return *state
+}
+
+func testMysqlService(client *fakeMysqlClient) *mysqlService {
+	return &mysqlService{
+		h: &ResourceHandler{
+			log:          slog.Default(),
+			retryPolicy:  DefaultRetryPolicy(),
+			waitTimeout:  time.Second,
+			pollInterval: time.Millisecond,
+		},
+		client: client,
+	}
+}
+
+func mysqlTask(act action.Action, state string) task.Task {
+	return task.NewTask(act, rs.ResourceSummary{
+		Identifier:     common.String("ocid1.mysqldbsystem.oc1..aaaa"),
+		ResourceType:   common.String("MysqlDbSystem"),
+		LifecycleState: common.String(state),
+	})
+}
+
+func TestMysql_LifecycleGuards(t *testing.T) {
+	s := &mysqlService{}
+
+	cases := []struct {
+		state             string
+		canStop, canStart bool
+	}{
+		{"ACTIVE", true, false},
+		{"INACTIVE", false, true},
+		{"CREATING", false, false},
+		{"UPDATING", false, false},
+		{"FAILED", false, false},
+		{"DELETING", false, false},
+		{"DELETED", false, false},
+		{"", false, false},
+	}
+
+	for _, c := range cases {
+		if got := s.CanStop(c.state); got != c.canStop {
+			t.Errorf("CanStop(%q) = %v, want %v", c.state, got, c.canStop)
+		}
+		if got := s.CanStart(c.state); got != c.canStart {
+			t.Errorf("CanStart(%q) = %v, want %v", c.state, got, c.canStart)
+		}
+	}
+}
+
+func TestMysql_StopHeatWaveFirst(t *testing.T) {
+	client := &fakeMysqlClient{db: "ACTIVE", hw: "ACTIVE", hwPolls: 1}
+	s := testMysqlService(client)
+
+	if err := s.Stop(mysqlTask(action.OFF, "ACTIVE")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The DB system is stopped only once HeatWave is inactive, even though
+	// wait-for-state is disabled
+	want := []string{"get dbsystem ACTIVE", "stop heatwave", "get heatwave UPDATING",
+		"get heatwave INACTIVE", "stop dbsystem"}
+	if !slices.Equal(client.events, want) {
+		t.Fatalf("expected %v, got %v", want, client.events)
+	}
+}
+
+func TestMysql_StartHeatWaveAfter(t *testing.T) {
+	client := &fakeMysqlClient{db: "INACTIVE", hw: "INACTIVE", dbPolls: 2}
+	s := testMysqlService(client)
+
+	if err := s.Start(mysqlTask(action.ON, "INACTIVE")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// HeatWave is started only once the DB system is active
+	want := []string{"start dbsystem", "get dbsystem UPDATING", "get dbsystem UPDATING",
+		"get dbsystem ACTIVE", "start heatwave"}
+	if !slices.Equal(client.events, want) {
+		t.Fatalf("expected %v, got %v", want, client.events)
+	}
+}
+
+func TestMysql_NoHeatWave(t *testing.T) {
+	client := &fakeMysqlClient{db: "ACTIVE"}
+	s := testMysqlService(client)
+
+	if err := s.Stop(mysqlTask(action.OFF, "ACTIVE")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Start(mysqlTask(action.ON, "INACTIVE")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"get dbsystem ACTIVE", "stop dbsystem", "start dbsystem",
+		"get dbsystem ACTIVE"}
+	if !slices.Equal(client.events, want) {
+		t.Fatalf("expected %v, got %v", want, client.events)
+	}
+}
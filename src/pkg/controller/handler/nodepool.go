@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"strconv"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	ce "github.com/oracle/oci-go-sdk/v65/containerengine"
+)
+
+const (
+	// Freeform tag holding the node count of a node pool scaled to zero
+	NODE_POOL_SIZE_TAG string = "frugal-saved-size"
+)
+
+func init() {
+	Register(Registration{
+		Name:         "Node Pool",
+		SearchType:   "nodepool",
+		ResourceType: "NodePool",
+		New:          newNodePoolService,
+	})
+}
+
+// nodePoolService scales OKE node pools to zero when turned off and restores the
+// saved node count when turned on. The node count is saved in a freeform tag on
+// the node pool so that no state is required between runs.
+type nodePoolService struct {
+	h      *ResourceHandler
+	client containerEngineClient
+}
+
+// containerEngineClient is the part of ce.ContainerEngineClient used to scale
+// node pools
+type containerEngineClient interface {
+	SetRegion(string)
+	GetNodePool(context.Context, ce.GetNodePoolRequest) (ce.GetNodePoolResponse, error)
+	UpdateNodePool(context.Context, ce.UpdateNodePoolRequest) (ce.UpdateNodePoolResponse, error)
+	GetWorkRequest(context.Context, ce.GetWorkRequestRequest) (ce.GetWorkRequestResponse, error)
+}
+
+func newNodePoolService(h *ResourceHandler) (Service, error) {
+	c, err := ce.NewContainerEngineClientWithConfigurationProvider(h.configProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	return &nodePoolService{h: h, client: &c}, nil
+}
+
+func (s *nodePoolService) Actions() action.Action {
+	return action.ON | action.OFF
+}
+
+func (s *nodePoolService) SetRegion(region string) {
+	s.client.SetRegion(region)
+}
+
+// Node pools stay ACTIVE when scaled to zero, size is checked when handled
+func (s *nodePoolService) CanStop(state string) bool {
+	return state == string(ce.NodePoolLifecycleStateActive) ||
+		state == string(ce.NodePoolLifecycleStateNeedsAttention)
+}
+
+func (s *nodePoolService) CanStart(state string) bool {
+	return s.CanStop(state)
+}
+
+// NeedsAction returns true if the node pool has nodes to scale down when
+// stopped, or a saved node count to restore when started
+func (s *nodePoolService) NeedsAction(t task.Task) (bool, error) {
+	pool, size, err := s.get(getResourceGroup(t), t.Resource.Identifier)
+	if err != nil {
+		return false, err
+	}
+
+	if t.Action == action.OFF {
+		return size > 0, nil
+	}
+	_, saved := pool.FreeformTags[NODE_POOL_SIZE_TAG]
+	return size == 0 && saved, nil
+}
+
+// Stop saves the current node count and scales the node pool to zero
+func (s *nodePoolService) Stop(t task.Task) error {
+	logGroup := getResourceGroup(t)
+
+	pool, size, err := s.get(logGroup, t.Resource.Identifier)
+	if err != nil {
+		return err
+	}
+	if size == 0 {
+		s.h.log.Info("Node Pool Handled - No Action Required",
+			slog.Int("Size", size),
+			slog.String("Action", "NONE"), logGroup)
+		return nil
+	}
+
+	tags := make(map[string]string, len(pool.FreeformTags)+1)
+	maps.Copy(tags, pool.FreeformTags)
+	tags[NODE_POOL_SIZE_TAG] = strconv.Itoa(size)
+
+	return s.update(t, size, 0, tags)
+}
+
+// Start restores the node count saved when the node pool was scaled to zero
+func (s *nodePoolService) Start(t task.Task) error {
+	logGroup := getResourceGroup(t)
+
+	pool, size, err := s.get(logGroup, t.Resource.Identifier)
+	if err != nil {
+		return err
+	}
+	if size > 0 {
+		s.h.log.Info("Node Pool Handled - No Action Required",
+			slog.Int("Size", size),
+			slog.String("Action", "NONE"), logGroup)
+		return nil
+	}
+
+	saved, ok := pool.FreeformTags[NODE_POOL_SIZE_TAG]
+	if !ok {
+		s.h.log.Warn("Node Pool Handled - No saved node count to restore",
+			slog.String("Tag", NODE_POOL_SIZE_TAG),
+			slog.String("Action", "NONE"), logGroup)
+		return nil
+	}
+
+	n, err := strconv.Atoi(saved)
+	if err != nil || n < 1 {
+		return fmt.Errorf("node pool %s has invalid saved node count %q",
+			*t.Resource.Identifier, saved)
+	}
+
+	tags := maps.Clone(pool.FreeformTags)
+	delete(tags, NODE_POOL_SIZE_TAG)
+
+	return s.update(t, size, n, tags)
+}
+
+// get returns the node pool and its current node count
+func (s *nodePoolService) get(logGroup slog.Attr, id *string) (ce.NodePool, int, error) {
+	var resp ce.GetNodePoolResponse
+	err := s.h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+		var err error
+		resp, err = s.client.GetNodePool(ctx, ce.GetNodePoolRequest{NodePoolId: id})
+		return resp.RawResponse, err
+	})
+	if err != nil {
+		return resp.NodePool, 0, err
+	}
+
+	if resp.NodeConfigDetails == nil || resp.NodeConfigDetails.Size == nil {
+		return resp.NodePool, 0, fmt.Errorf("node pool %s has no node config size to scale", *id)
+	}
+
+	return resp.NodePool, *resp.NodeConfigDetails.Size, nil
+}
+
+// update sets the node count and freeform tags of the node pool
+func (s *nodePoolService) update(t task.Task, from, to int, tags map[string]string) error {
+	logGroup := getResourceGroup(t)
+	id := t.Resource.Identifier
+
+	req := ce.UpdateNodePoolRequest{
+		NodePoolId: id,
+		UpdateNodePoolDetails: ce.UpdateNodePoolDetails{
+			NodeConfigDetails: &ce.UpdateNodePoolNodeConfigDetails{
+				Size: common.Int(to),
+			},
+			FreeformTags: tags,
+		},
+	}
+
+	var resp ce.UpdateNodePoolResponse
+	err := s.h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+		var err error
+		resp, err = s.client.UpdateNodePool(ctx, req)
+		return resp.RawResponse, err
+	})
+	if err != nil {
+		return err
+	}
+	s.h.log.Info("Node Pool Handled",
+		slog.String("Action", "SCALE"),
+		slog.Int("From", from),
+		slog.Int("To", to),
+		slog.String("Status Message", resp.RawResponse.Status),
+		logGroup)
+
+	if resp.OpcWorkRequestId == nil {
+		return nil
+	}
+
+	return s.h.confirm(t, s.workRequestState(resp.OpcWorkRequestId),
+		string(ce.WorkRequestStatusSucceeded))
+}
+
+// workRequestState returns a getter for the status of a container engine work
+// request
+func (s *nodePoolService) workRequestState(id *string) stateGetter {
+	return func(ctx context.Context) (string, *http.Response, error) {
+		resp, err := s.client.GetWorkRequest(ctx,
+			ce.GetWorkRequestRequest{WorkRequestId: id})
+		return string(resp.Status), resp.RawResponse, err
+	}
+}
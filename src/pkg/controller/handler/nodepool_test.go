@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	ce "github.com/oracle/oci-go-sdk/v65/containerengine"
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+// fakeContainerEngineClient serves a single node pool and records updates
+type fakeContainerEngineClient struct {
+	size    int
+	tags    map[string]string
+	updates []ce.UpdateNodePoolDetails
+}
+
+func (c *fakeContainerEngineClient) SetRegion(string) {}
+
+func (c *fakeContainerEngineClient) GetNodePool(context.Context,
+	ce.GetNodePoolRequest) (ce.GetNodePoolResponse, error) {
+	return ce.GetNodePoolResponse{
+		RawResponse: &http.Response{StatusCode: http.StatusOK},
+		NodePool: ce.NodePool{
+			FreeformTags: c.tags,
+			NodeConfigDetails: &ce.NodePoolNodeConfigDetails{
+				Size: common.Int(c.size),
+			},
+		},
+	}, nil
+}
+
+func (c *fakeContainerEngineClient) UpdateNodePool(_ context.Context,
+	req ce.UpdateNodePoolRequest) (ce.UpdateNodePoolResponse, error) {
+	c.updates = append(c.updates, req.UpdateNodePoolDetails)
+	return ce.UpdateNodePoolResponse{
+		RawResponse: &http.Response{Status: "202 Accepted", StatusCode: http.StatusAccepted},
+	}, nil
+}
+
+func (c *fakeContainerEngineClient) GetWorkRequest(context.Context,
+	ce.GetWorkRequestRequest) (ce.GetWorkRequestResponse, error) {
+	return ce.GetWorkRequestResponse{}, nil
+}
+
+func nodePoolTask(act action.Action) task.Task {
+	return task.NewTask(act, rs.ResourceSummary{
+		Identifier:     common.String("ocid1.nodepool.oc1..aaaa"),
+		ResourceType:   common.String("NodePool"),
+		LifecycleState: common.String("ACTIVE"),
+	})
+}
+
+func TestNodePool_SavedSize(t *testing.T) {
+	cases := []struct {
+		name    string
+		act     action.Action
+		size    int
+		tags    map[string]string
+		wantErr bool
+		update  bool
+		to      int
+		tag     string // Saved size tag after update, empty if removed
+	}{
+		{"stop saves size", action.OFF, 3, map[string]string{"team": "dev"},
+			false, true, 0, "3"},
+		{"stop already zero", action.OFF, 0, nil, false, false, 0, ""},
+		{"start restores size", action.ON, 0,
+			map[string]string{"team": "dev", NODE_POOL_SIZE_TAG: "3"}, false, true, 3, ""},
+		{"start already scaled", action.ON, 2,
+			map[string]string{NODE_POOL_SIZE_TAG: "3"}, false, false, 0, ""},
+		{"start missing tag", action.ON, 0, map[string]string{"team": "dev"},
+			false, false, 0, ""},
+		{"start non-numeric tag", action.ON, 0,
+			map[string]string{NODE_POOL_SIZE_TAG: "three"}, true, false, 0, ""},
+		{"start zero tag", action.ON, 0,
+			map[string]string{NODE_POOL_SIZE_TAG: "0"}, true, false, 0, ""},
+	}
+
+	for _, c := range cases {
+		client := &fakeContainerEngineClient{size: c.size, tags: c.tags}
+		s := &nodePoolService{
+			h:      &ResourceHandler{log: slog.Default(), retryPolicy: DefaultRetryPolicy()},
+			client: client,
+		}
+
+		var err error
+		if c.act == action.OFF {
+			err = s.Stop(nodePoolTask(c.act))
+		} else {
+			err = s.Start(nodePoolTask(c.act))
+		}
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+
+		if !c.update {
+			if len(client.updates) != 0 {
+				t.Errorf("%s: expected no update, got %+v", c.name, client.updates)
+			}
+			continue
+		}
+		if len(client.updates) != 1 {
+			t.Errorf("%s: expected 1 update, got %d", c.name, len(client.updates))
+			continue
+		}
+
+		u := client.updates[0]
+		if *u.NodeConfigDetails.Size != c.to {
+			t.Errorf("%s: expected size %d, got %d", c.name, c.to, *u.NodeConfigDetails.Size)
+		}
+		if got := u.FreeformTags[NODE_POOL_SIZE_TAG]; got != c.tag {
+			t.Errorf("%s: expected saved size tag %q, got %q", c.name, c.tag, got)
+		}
+		if u.FreeformTags["team"] != "dev" {
+			t.Errorf("%s: expected other tags to be kept, got %v", c.name, u.FreeformTags)
+		}
+		if c.act == action.OFF && c.tags[NODE_POOL_SIZE_TAG] != "" {
+			t.Errorf("%s: expected tags read from the node pool not to be modified", c.name)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/oda"
+)
+
+func init() {
+	RegisterPaaS(PaaSService{
+		Name:          "Digital Assistant Instance",
+		SearchType:    "odainstance",
+		ResourceType:  "OdaInstance",
+		RunningStates: []string{string(oda.OdaInstanceLifecycleStateActive)},
+		StoppedStates: []string{string(oda.OdaInstanceLifecycleStateInactive)},
+		NewClient: func(cp common.ConfigurationProvider) (PaaSClient, error) {
+			c, err := oda.NewOdaClientWithConfigurationProvider(cp)
+			return &odaClient{c}, err
+		},
+	})
+}
+
+type odaClient struct {
+	oda.OdaClient
+}
+
+func (c *odaClient) Start(ctx context.Context, id *string) (*http.Response, error) {
+	resp, err := c.StartOdaInstance(ctx, oda.StartOdaInstanceRequest{OdaInstanceId: id})
+	return resp.RawResponse, err
+}
+
+func (c *odaClient) Stop(ctx context.Context, id *string) (*http.Response, error) {
+	resp, err := c.StopOdaInstance(ctx, oda.StopOdaInstanceRequest{OdaInstanceId: id})
+	return resp.RawResponse, err
+}
+
+func (c *odaClient) State(ctx context.Context, id *string) (string, *http.Response, error) {
+	resp, err := c.GetOdaInstance(ctx, oda.GetOdaInstanceRequest{OdaInstanceId: id})
+	return string(resp.LifecycleState), resp.RawResponse, err
+}
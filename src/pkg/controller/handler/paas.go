@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/common"
+)
+
+// PaaSClient adapts a service SDK client to the start, stop, and get operations
+// needed to schedule one of its resources.
+type PaaSClient interface {
+	SetRegion(string)
+	Start(context.Context, *string) (*http.Response, error)
+	Stop(context.Context, *string) (*http.Response, error)
+	State(context.Context, *string) (string, *http.Response, error)
+}
+
+// PaaSService describes a service whose resources can be turned on and off with
+// a single start/stop API pair. Registering a PaaSService adds it to the search
+// query, the supported services, and the resource handler.
+type PaaSService struct {
+	Name          string   // Name used in logs [ex. GoldenGate Deployment]
+	SearchType    string   // Resource search type [ex. goldengatedeployment]
+	ResourceType  string   // Resource type returned by search [ex. GoldenGateDeployment]
+	RunningStates []string // States in which the resource may be stopped
+	StoppedStates []string // States in which the resource may be started
+	NewClient     func(common.ConfigurationProvider) (PaaSClient, error)
+}
+
+// RegisterPaaS adds a PaaS service to the set handled by ResourceHandler. Must
+// be called before any handler is created, typically from init.
+func RegisterPaaS(s PaaSService) {
+	if s.NewClient == nil {
+		panic(fmt.Sprintf("invalid PaaS service registration %q", s.Name))
+	}
+
+	Register(Registration{
+		Name:         s.Name,
+		SearchType:   s.SearchType,
+		ResourceType: s.ResourceType,
+		New: func(h *ResourceHandler) (Service, error) {
+			c, err := s.NewClient(h.configProvider)
+			if err != nil {
+				return nil, err
+			}
+			return &paasService{h: h, desc: s, client: c}, nil
+		},
+	})
+}
+
+// paasService schedules the resources of a registered PaaS service
+type paasService struct {
+	h      *ResourceHandler
+	desc   PaaSService
+	client PaaSClient
+}
+
+func (s *paasService) Actions() action.Action {
+	return action.ON | action.OFF
+}
+
+func (s *paasService) SetRegion(region string) {
+	s.client.SetRegion(region)
+}
+
+func (s *paasService) CanStop(state string) bool {
+	return slices.Contains(s.desc.RunningStates, strings.ToUpper(state))
+}
+
+func (s *paasService) CanStart(state string) bool {
+	return slices.Contains(s.desc.StoppedStates, strings.ToUpper(state))
+}
+
+func (s *paasService) Stop(t task.Task) error {
+	logGroup := getResourceGroup(t)
+
+	var resp *http.Response
+	err := s.h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+		var err error
+		resp, err = s.client.Stop(ctx, t.Resource.Identifier)
+		return resp, err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.h.log.Info("Stopped "+s.desc.Name,
+		slog.String("Action", "STOP"),
+		slog.String("Status", resp.Status),
+		logGroup)
+
+	return s.h.confirm(t, s.state(t.Resource.Identifier),
+		s.desc.StoppedStates...)
+}
+
+func (s *paasService) Start(t task.Task) error {
+	logGroup := getResourceGroup(t)
+
+	var resp *http.Response
+	err := s.h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+		var err error
+		resp, err = s.client.Start(ctx, t.Resource.Identifier)
+		return resp, err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.h.log.Info("Started "+s.desc.Name,
+		slog.String("Action", "START"),
+		slog.String("Status", resp.Status),
+		logGroup)
+
+	return s.h.confirm(t, s.state(t.Resource.Identifier),
+		s.desc.RunningStates...)
+}
+
+// WaitFor waits for a starting or stopping resource to reach a running or stopped
+// state
+func (s *paasService) WaitFor(t task.Task) error {
+	targets := s.desc.StoppedStates
+	if t.Action == action.ON {
+		targets = s.desc.RunningStates
+	}
+
+	return s.h.confirm(t, s.state(t.Resource.Identifier), targets...)
+}
+
+// state returns a getter for the lifecycle state of a PaaS resource
+func (s *paasService) state(id *string) stateGetter {
+	return func(ctx context.Context) (string, *http.Response, error) {
+		return s.client.State(ctx, id)
+	}
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	tokenpool "github.com/flynnkc/token-pool"
+)
+
+// fakePaaSClient records the calls made against it
+type fakePaaSClient struct {
+	starts, stops, polls int
+}
+
+func (c *fakePaaSClient) SetRegion(string) {}
+
+func (c *fakePaaSClient) Start(context.Context, *string) (*http.Response, error) {
+	c.starts++
+	return &http.Response{Status: "202 Accepted"}, nil
+}
+
+func (c *fakePaaSClient) Stop(context.Context, *string) (*http.Response, error) {
+	c.stops++
+	return &http.Response{Status: "202 Accepted"}, nil
+}
+
+func (c *fakePaaSClient) State(context.Context, *string) (string, *http.Response, error) {
+	c.polls++
+	return "ACTIVE", okResponse(), nil
+}
+
+func TestSearchTypes_IncludesRegistered(t *testing.T) {
+	types := SearchTypes()
+	for _, want := range []string{"instance", "instancepool", "nodepool",
+		"dbsystem", "autonomousdatabase", "mysqldbsystem", "analyticsinstance",
+		"integrationinstance", "goldengatedeployment", "odainstance"} {
+		if !slices.Contains(types, want) {
+			t.Errorf("expected search types to contain %s, got %v", want, types)
+		}
+	}
+}
+
+func TestHandleResource_PaaSLifecycleGuards(t *testing.T) {
+	h := &ResourceHandler{
+		log:         slog.Default(),
+		retryPolicy: DefaultRetryPolicy(),
+		tp:          tokenpool.NewTokenPool(8, 8, time.Second),
+	}
+
+	cases := []struct {
+		act           action.Action
+		state         string
+		starts, stops int
+	}{
+		{action.OFF, "ACTIVE", 0, 1},
+		{action.OFF, "INACTIVE", 0, 0},
+		{action.ON, "INACTIVE", 1, 0},
+		{action.ON, "ACTIVE", 0, 0},
+		{action.ON, "DELETED", 0, 0},
+		{action.OFF, "UPDATING", 0, 0},
+	}
+
+	for _, c := range cases {
+		client := &fakePaaSClient{}
+		h.services = map[string]serviceCache{"": {
+			"TestService": {svc: &paasService{h: h, desc: testService(), client: client}},
+		}}
+		if err := h.HandleResource(testTask(c.act, c.state)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if client.starts != c.starts || client.stops != c.stops {
+			t.Errorf("action %v state %s: got %d starts %d stops, want %d starts %d stops",
+				c.act, c.state, client.starts, client.stops, c.starts, c.stops)
+		}
+	}
+}
+
+func TestHandleResource_WaitsForTransitioningPrerequisite(t *testing.T) {
+	h := &ResourceHandler{
+		log:          slog.Default(),
+		retryPolicy:  DefaultRetryPolicy(),
+		tp:           tokenpool.NewTokenPool(8, 8, time.Second),
+		waitTimeout:  20 * time.Millisecond,
+		pollInterval: time.Millisecond,
+	}
+
+	cases := []struct {
+		act   action.Action
+		state string
+		wait  bool
+		polls bool
+	}{
+		{action.ON, "STARTING", true, true},
+		{action.ON, "STARTING", false, false}, // Not a prerequisite
+		{action.OFF, "STOPPING", true, true},
+		{action.ON, "STOPPING", true, false}, // Not moving toward the target
+		{action.ON, "ACTIVE", true, false},
+	}
+
+	for _, c := range cases {
+		client := &fakePaaSClient{}
+		h.services = map[string]serviceCache{"": {
+			"TestService": {svc: &paasService{h: h, desc: testService(), client: client}},
+		}}
+
+		tk := testTask(c.act, c.state)
+		tk.Wait = c.wait
+		err := h.HandleResource(tk)
+
+		// The fake settles ACTIVE, so prerequisites stopping never arrive
+		if c.act == action.OFF && c.polls {
+			if !errors.Is(err, ErrWaitTimeout) {
+				t.Errorf("action %v state %s: expected wait timeout, got %v", c.act, c.state, err)
+			}
+		} else if err != nil {
+			t.Errorf("action %v state %s: unexpected error: %v", c.act, c.state, err)
+		}
+		if client.starts+client.stops != 0 {
+			t.Errorf("action %v state %s: expected no actions", c.act, c.state)
+		}
+		if (client.polls > 0) != c.polls {
+			t.Errorf("action %v state %s: got %d polls, want polls %v", c.act, c.state,
+				client.polls, c.polls)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+)
+
+var (
+	registry   map[string]Registration = make(map[string]Registration)
+	registryMu sync.RWMutex
+)
+
+// Service schedules the resources of a single resource type. ResourceHandler
+// checks the supported actions and lifecycle state of a resource before calling
+// Start or Stop.
+type Service interface {
+	// Actions returns the actions the service supports
+	Actions() action.Action
+	// CanStart returns true if a resource in the lifecycle state can be started
+	CanStart(state string) bool
+	// CanStop returns true if a resource in the lifecycle state can be stopped
+	CanStop(state string) bool
+	// Start turns the resource on
+	Start(task.Task) error
+	// Stop turns the resource off
+	Stop(task.Task) error
+	// SetRegion changes the region of the underlying clients
+	SetRegion(string)
+}
+
+// Skipper is implemented by services that deliberately leave some resources
+// untouched. Skip is checked before a rate limit token is acquired.
+type Skipper interface {
+	// Skip returns ErrSkipped if the resource is left untouched
+	Skip(task.Task) error
+}
+
+// Checker is implemented by services whose resources may need no action in a
+// lifecycle state CanStart or CanStop accepts, such as a DB system with stopped
+// nodes. NeedsAction is used to count the resources a run would change.
+type Checker interface {
+	// NeedsAction returns true if starting or stopping the resource changes it
+	NeedsAction(task.Task) (bool, error)
+}
+
+// Waiter is implemented by services that can wait for a resource already moving
+// toward the target state of a task, such as an instance that is STARTING
+type Waiter interface {
+	// WaitFor waits for the resource to reach the target state of the task
+	WaitFor(task.Task) error
+}
+
+// Registration describes a resource type and how to build its Service
+type Registration struct {
+	Name         string // Name used in logs [ex. Compute Instance]
+	SearchType   string // Resource search type [ex. instance]
+	ResourceType string // Resource type returned by search [ex. Instance]
+	// New builds the service using the shared handler for logging, retries and
+	// configuration
+	New func(*ResourceHandler) (Service, error)
+	// Enabled returns true if the handler's configuration acts on the resource
+	// type. Optional, nil is always enabled.
+	Enabled func(*ResourceHandler) bool
+}
+
+// Register adds a resource type to the set handled by ResourceHandler. Must be
+// called before any handler is created, typically from init.
+func Register(r Registration) {
+	if r.SearchType == "" || r.ResourceType == "" || r.New == nil {
+		panic(fmt.Sprintf("invalid resource registration %q", r.Name))
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[r.ResourceType]; ok {
+		panic(fmt.Sprintf("resource type %s registered twice", r.ResourceType))
+	}
+
+	registry[r.ResourceType] = r
+}
+
+// Registrations returns every registered resource type ordered by search type
+func Registrations() []Registration {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	regs := make([]Registration, 0, len(registry))
+	for _, r := range registry {
+		regs = append(regs, r)
+	}
+	slices.SortFunc(regs, func(a, b Registration) int {
+		return strings.Compare(a.SearchType, b.SearchType)
+	})
+
+	return regs
+}
+
+// SearchTypes returns the resource search types of every supported service
+func SearchTypes() []string {
+	regs := Registrations()
+	types := make([]string, len(regs))
+	for i, r := range regs {
+		types[i] = r.SearchType
+	}
+
+	return types
+}
+
+// registration returns the registration of a resource type
+func registration(resourceType string) (Registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	r, ok := registry[resourceType]
+	return r, ok
+}
+
+// registrationName returns the log name of a registered resource type
+func registrationName(resourceType string) string {
+	if r, ok := registration(resourceType); ok && r.Name != "" {
+		return r.Name
+	}
+
+	return resourceType
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+)
+
+const (
+	DEFAULT_RETRY_ATTEMPTS int           = 5
+	DEFAULT_RETRY_BASE     time.Duration = 2 * time.Second
+	DEFAULT_RETRY_CAP      time.Duration = 30 * time.Second
+	DEFAULT_RETRY_BUDGET   time.Duration = 2 * time.Minute
+)
+
+var (
+	// Service error codes that will not succeed no matter how many times they are
+	// retried
+	nonRetryableCodes map[string]bool = map[string]bool{
+		"IncorrectState":                         true,
+		"NotAuthorizedOrNotFound":                true,
+		"NotAuthenticated":                       true,
+		"NotAuthorizedOrResourceAlreadyExists":   true,
+		"InvalidParameter":                       true,
+		"MissingParameter":                       true,
+		"CannotParseRequest":                     true,
+		"LimitExceeded":                          true,
+		"QuotaExceeded":                          true,
+		"RelatedResourceNotAuthorizedOrNotFound": true,
+	}
+)
+
+// RetryPolicy bounds the retries made for a single handler action. Delays grow
+// exponentially from BaseDelay up to MaxDelay with full jitter, and no retry is
+// attempted if it would exceed the total Budget.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Budget      time.Duration
+}
+
+// operation is a single request against an OCI API returning the raw response so
+// that headers can be inspected on failure
+type operation func(context.Context) (*http.Response, error)
+
+// DefaultRetryPolicy returns the retry policy used when none is provided
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: DEFAULT_RETRY_ATTEMPTS,
+		BaseDelay:   DEFAULT_RETRY_BASE,
+		MaxDelay:    DEFAULT_RETRY_CAP,
+		Budget:      DEFAULT_RETRY_BUDGET,
+	}
+}
+
+// IsRetryable classifies an error returned from an OCI API call. Throttling,
+// server side failures, and network timeouts are retryable; client errors such
+// as IncorrectState or NotAuthorizedOrNotFound are not.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if se, ok := common.IsServiceError(err); ok {
+		if nonRetryableCodes[se.GetCode()] {
+			return false
+		}
+
+		switch code := se.GetHTTPStatusCode(); {
+		case code == http.StatusTooManyRequests:
+			return true
+		case code == http.StatusNotImplemented:
+			return false
+		case code >= 500:
+			return true
+		default:
+			return false
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || common.IsNetworkError(err) {
+		return true
+	}
+
+	return false
+}
+
+// backoff returns the delay before the next attempt. Delay is a random duration
+// up to BaseDelay * 2^(attempt-1) capped at MaxDelay, but never less than a
+// server provided Retry-After.
+func (p RetryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	ceiling := p.MaxDelay
+	if attempt < 32 {
+		if d := p.BaseDelay << (attempt - 1); d > 0 && d < ceiling {
+			ceiling = d
+		}
+	}
+
+	var wait time.Duration
+	if ceiling > 0 {
+		wait = rand.N(ceiling + 1)
+	}
+
+	if retryAfter > wait {
+		wait = retryAfter
+	}
+
+	return wait
+}
+
+// retryAfter reads the Retry-After header from a response in either delay-seconds
+// or HTTP-date form. Returns zero if not present or unreadable.
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
+
+// retry runs op until it succeeds, returns a non-retryable error, or the retry
+// policy is exhausted. Each attempt is given its own timeout.
+func (h *ResourceHandler) retry(logGroup slog.Attr, op operation) error {
+	p := h.retryPolicy
+	deadline := time.Now().Add(p.Budget)
+
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_INTERVAL)
+		resp, err := op(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		if !IsRetryable(err) {
+			return err
+		}
+
+		if attempt >= p.MaxAttempts {
+			return fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		wait := p.backoff(attempt, retryAfter(resp))
+		if time.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("retry budget of %s exhausted after %d attempts: %w",
+				p.Budget, attempt, err)
+		}
+
+		h.log.Warn("Retrying request",
+			slog.Int("Attempt", attempt),
+			slog.Duration("Wait", wait),
+			slog.String("error", err.Error()),
+			logGroup)
+		time.Sleep(wait)
+	}
+}
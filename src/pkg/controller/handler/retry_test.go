@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// fakeServiceError satisfies common.ServiceError
+type fakeServiceError struct {
+	status int
+	code   string
+}
+
+func (e fakeServiceError) Error() string           { return fmt.Sprintf("%d %s", e.status, e.code) }
+func (e fakeServiceError) GetHTTPStatusCode() int  { return e.status }
+func (e fakeServiceError) GetMessage() string      { return e.code }
+func (e fakeServiceError) GetCode() string         { return e.code }
+func (e fakeServiceError) GetOpcRequestID() string { return "" }
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{fakeServiceError{429, "TooManyRequests"}, true},
+		{fakeServiceError{500, "InternalServerError"}, true},
+		{fakeServiceError{503, "ServiceUnavailable"}, true},
+		{fakeServiceError{501, "MethodNotImplemented"}, false},
+		{fakeServiceError{409, "IncorrectState"}, false},
+		{fakeServiceError{404, "NotAuthorizedOrNotFound"}, false},
+		{fakeServiceError{400, "InvalidParameter"}, false},
+		{context.DeadlineExceeded, true},
+		{fmt.Errorf("wrapped: %w", context.DeadlineExceeded), true},
+		{errors.New("something else"), false},
+	}
+
+	for _, c := range cases {
+		if got := IsRetryable(c.err); got != c.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestBackoff_Bounds(t *testing.T) {
+	p := RetryPolicy{
+		MaxAttempts: 10,
+		BaseDelay:   time.Second,
+		MaxDelay:    5 * time.Second,
+		Budget:      time.Minute,
+	}
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		for range 50 {
+			if d := p.backoff(attempt, 0); d < 0 || d > p.MaxDelay {
+				t.Fatalf("attempt %d: backoff %s out of bounds", attempt, d)
+			}
+		}
+	}
+
+	// Retry-After acts as a floor even when above the cap
+	if d := p.backoff(1, 20*time.Second); d != 20*time.Second {
+		t.Fatalf("expected Retry-After to be honored, got %s", d)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	if d := retryAfter(resp); d != 0 {
+		t.Fatalf("expected 0 without header, got %s", d)
+	}
+
+	resp.Header.Set("Retry-After", "7")
+	if d := retryAfter(resp); d != 7*time.Second {
+		t.Fatalf("expected 7s, got %s", d)
+	}
+
+	if d := retryAfter(nil); d != 0 {
+		t.Fatalf("expected 0 for nil response, got %s", d)
+	}
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/configuration"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/database"
+)
+
+func init() {
+	// ExaCS
+	Register(Registration{
+		Name:         "Cloud VM Cluster",
+		SearchType:   "cloudvmcluster",
+		ResourceType: "CloudVmCluster",
+		New:          newVmClusterService,
+		Enabled:      vmClustersEnabled,
+	})
+
+	// Exadata Cloud@Customer
+	Register(Registration{
+		Name:         "VM Cluster",
+		SearchType:   "vmcluster",
+		ResourceType: "VmCluster",
+		New:          newVmClusterService,
+		Enabled:      vmClustersEnabled,
+	})
+}
+
+// vmClustersEnabled returns true if the Exadata policy handles VM clusters so
+// they are only searched for when they may be acted on
+func vmClustersEnabled(h *ResourceHandler) bool {
+	return h.exadataPolicy == configuration.EXADATA_VM_CLUSTER
+}
+
+// vmClusterService stops and starts the Database Nodes of Exadata VM clusters
+// one at a time so that a failed node leaves the rest of the cluster untouched.
+// VM clusters are skipped unless the Exadata policy allows them.
+type vmClusterService struct {
+	dbNodeHandler
+}
+
+func newVmClusterService(h *ResourceHandler) (Service, error) {
+	db, err := database.NewDatabaseClientWithConfigurationProvider(h.configProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vmClusterService{dbNodeHandler{h: h, database: &db}}, nil
+}
+
+func (s *vmClusterService) Actions() action.Action {
+	return action.ON | action.OFF
+}
+
+// Node states are checked individually when handling the VM cluster
+func (s *vmClusterService) CanStop(state string) bool {
+	return state != "TERMINATING" && state != "TERMINATED" && state != "FAILED"
+}
+
+func (s *vmClusterService) CanStart(state string) bool {
+	return s.CanStop(state)
+}
+
+// Skip leaves VM clusters untouched unless the Exadata policy handles them
+func (s *vmClusterService) Skip(task.Task) error {
+	if s.h.exadataPolicy != configuration.EXADATA_VM_CLUSTER {
+		return ErrSkipped{Reason: fmt.Sprintf("VM cluster and exadata policy is %s",
+			s.h.exadataPolicy)}
+	}
+
+	return nil
+}
+
+// NeedsAction returns true if any node of the VM cluster is not already in the
+// target state
+func (s *vmClusterService) NeedsAction(t task.Task) (bool, error) {
+	return s.needsAction(t, database.ListDbNodesRequest{
+		VmClusterId: t.Resource.Identifier,
+	})
+}
+
+func (s *vmClusterService) Stop(t task.Task) error {
+	return s.handle(t)
+}
+
+func (s *vmClusterService) Start(t task.Task) error {
+	return s.handle(t)
+}
+
+func (s *vmClusterService) handle(t task.Task) error {
+	if err := s.Skip(t); err != nil {
+		return err
+	}
+
+	nodes, err := s.getDbNodes(t, database.ListDbNodesRequest{
+		VmClusterId: t.Resource.Identifier,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.handleNodes(t, nodes, true)
+}
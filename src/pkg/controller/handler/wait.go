@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+)
+
+const (
+	DEFAULT_WAIT_TIMEOUT  time.Duration = 10 * time.Minute
+	DEFAULT_POLL_INTERVAL time.Duration = 15 * time.Second
+)
+
+var (
+	ErrWaitTimeout error = errors.New("timed out waiting for resource to reach target state")
+
+	// States from which a resource will not reach a start/stop target
+	failedStates map[string]bool = map[string]bool{
+		"FAILED":      true,
+		"TERMINATING": true,
+		"TERMINATED":  true,
+		"DELETING":    true,
+		"DELETED":     true,
+		"CANCELED":    true,
+	}
+)
+
+// ErrUnexpectedState indicates a resource settled in a state from which it will
+// not reach the target state
+type ErrUnexpectedState struct {
+	State  string
+	Target []string
+}
+
+func (e ErrUnexpectedState) Error() string {
+	return fmt.Sprintf("resource entered state %s while waiting for %s", e.State,
+		strings.Join(e.Target, "/"))
+}
+
+// stateGetter fetches the current lifecycle state of a single resource
+type stateGetter func(context.Context) (string, *http.Response, error)
+
+// confirmation is a resource to confirm reaches one of targets
+type confirmation struct {
+	t       task.Task
+	get     stateGetter
+	targets []string
+}
+
+// confirmAll confirms every resource concurrently, returning the errors of those
+// that did not reach their targets
+func (h *ResourceHandler) confirmAll(cs []confirmation) []error {
+	results := make([]error, len(cs))
+
+	var wg sync.WaitGroup
+	for i, c := range cs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := h.confirm(c.t, c.get, c.targets...); err != nil {
+				results[i] = fmt.Errorf("confirm %s: %w", *c.t.Resource.Identifier, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	errs := make([]error, 0)
+	for _, err := range results {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// escalate runs the escalation of a graceful stop. The worker waits for it if
+// wait-for-state is enabled or the task requires it, otherwise it runs in the
+// background so that the worker moves on while the grace period elapses.
+func (h *ResourceHandler) escalate(t task.Task, escalate func(task.Task) error) error {
+	if h.wait || t.Wait {
+		return escalate(t)
+	}
+
+	h.escalations.Add(1)
+	go func() {
+		defer h.escalations.Done()
+		if err := escalate(t); err != nil {
+			h.log.Error("Unable to escalate graceful stop",
+				slog.String("error", err.Error()),
+				getResourceGroup(t))
+		}
+	}()
+
+	return nil
+}
+
+// Wait blocks until graceful stops escalating in the background are done
+func (h *ResourceHandler) Wait() {
+	h.escalations.Wait()
+}
+
+// confirm waits for the resource to reach one of targets if wait-for-state is
+// enabled or the task requires it, otherwise returns immediately
+func (h *ResourceHandler) confirm(t task.Task, get stateGetter,
+	targets ...string) error {
+	if !h.wait && !t.Wait {
+		return nil
+	}
+
+	return h.waitForState(getResourceGroup(t), h.waitTimeout, get, targets...)
+}
+
+// waitForState polls get until the resource reports one of targets, enters a
+// failed state, or timeout elapses. Each poll is retried per the retry policy.
+func (h *ResourceHandler) waitForState(logGroup slog.Attr, timeout time.Duration,
+	get stateGetter, targets ...string) error {
+	deadline := time.Now().Add(timeout)
+	start := time.Now()
+
+	for {
+		var state string
+		err := h.retry(logGroup, func(ctx context.Context) (*http.Response, error) {
+			s, resp, err := get(ctx)
+			state = s
+			return resp, err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, target := range targets {
+			if strings.EqualFold(state, target) {
+				h.log.Info("Resource reached target state",
+					slog.String("State", state),
+					slog.Duration("Elapsed", time.Since(start)),
+					logGroup)
+				return nil
+			}
+		}
+
+		if failedStates[strings.ToUpper(state)] {
+			return ErrUnexpectedState{State: state, Target: targets}
+		}
+
+		if time.Now().Add(h.pollInterval).After(deadline) {
+			return fmt.Errorf("%w %s after %s (last state %s)", ErrWaitTimeout,
+				strings.Join(targets, "/"), timeout, state)
+		}
+
+		h.log.Debug("Waiting for resource state",
+			slog.String("State", state),
+			slog.String("Target", strings.Join(targets, "/")),
+			logGroup)
+		time.Sleep(h.pollInterval)
+	}
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+func testWaitHandler() *ResourceHandler {
+	return &ResourceHandler{
+		log:          slog.Default(),
+		retryPolicy:  DefaultRetryPolicy(),
+		wait:         true,
+		waitTimeout:  time.Second,
+		pollInterval: time.Millisecond,
+	}
+}
+
+// states returns a getter reporting each of states in turn, then the last
+func states(states ...string) (stateGetter, *int) {
+	calls := 0
+	return func(context.Context) (string, *http.Response, error) {
+		s := states[min(calls, len(states)-1)]
+		calls++
+		return s, &http.Response{StatusCode: http.StatusOK}, nil
+	}, &calls
+}
+
+func TestConfirm(t *testing.T) {
+	cases := []struct {
+		name    string
+		states  []string
+		timeout time.Duration
+		want    error
+		calls   int
+	}{
+		{"reaches target", []string{"STOPPING", "STOPPING", "STOPPED"}, time.Second, nil, 3},
+		{"target case insensitive", []string{"stopped"}, time.Second, nil, 1},
+		{"failed state", []string{"STOPPING", "FAILED"}, time.Second, ErrUnexpectedState{}, 2},
+		{"timeout", []string{"STOPPING"}, 5 * time.Millisecond, ErrWaitTimeout, 0},
+	}
+
+	for _, c := range cases {
+		h := testWaitHandler()
+		h.waitTimeout = c.timeout
+		get, calls := states(c.states...)
+
+		err := h.confirm(testTask(action.OFF, "RUNNING"), get, "STOPPED")
+		switch want := c.want.(type) {
+		case nil:
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", c.name, err)
+			}
+		case ErrUnexpectedState:
+			if !errors.As(err, &want) || want.State != "FAILED" {
+				t.Errorf("%s: expected ErrUnexpectedState FAILED, got %v", c.name, err)
+			}
+		default:
+			if !errors.Is(err, want) {
+				t.Errorf("%s: expected %v, got %v", c.name, want, err)
+			}
+		}
+		if c.calls > 0 && *calls != c.calls {
+			t.Errorf("%s: expected %d polls, got %d", c.name, c.calls, *calls)
+		}
+	}
+}
+
+func TestConfirm_Disabled(t *testing.T) {
+	h := testWaitHandler()
+	h.wait = false
+	h.waitTimeout = 5 * time.Millisecond
+	get, calls := states("STOPPING")
+
+	if err := h.confirm(testTask(action.OFF, "RUNNING"), get, "STOPPED"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *calls != 0 {
+		t.Fatalf("expected no polls when waiting is disabled, got %d", *calls)
+	}
+
+	// Tasks requiring confirmation wait regardless
+	tk := testTask(action.OFF, "RUNNING")
+	tk.Wait = true
+	if err := h.confirm(tk, get, "STOPPED"); !errors.Is(err, ErrWaitTimeout) {
+		t.Fatalf("expected task requiring confirmation to wait, got %v", err)
+	}
+}
+
+func TestConfirmAll_Concurrent(t *testing.T) {
+	h := testWaitHandler()
+
+	// Each resource stops only once every resource has been polled, which never
+	// happens if they are confirmed one at a time
+	const n = 3
+	var polled sync.WaitGroup
+	polled.Add(n)
+	all := make(chan struct{})
+	go func() {
+		polled.Wait()
+		close(all)
+	}()
+
+	cs := make([]confirmation, 0, n+1)
+	for range n {
+		var once sync.Once
+		cs = append(cs, confirmation{
+			t: testTask(action.OFF, "RUNNING"),
+			get: func(context.Context) (string, *http.Response, error) {
+				once.Do(polled.Done)
+				select {
+				case <-all:
+					return "STOPPED", &http.Response{}, nil
+				default:
+					return "STOPPING", &http.Response{}, nil
+				}
+			},
+			targets: []string{"STOPPED"},
+		})
+	}
+
+	failed, _ := states("FAILED")
+	cs = append(cs, confirmation{
+		t: task.NewTask(action.OFF, rs.ResourceSummary{
+			Identifier:     common.String("ocid1.test.oc1..failed"),
+			ResourceType:   common.String("TestService"),
+			LifecycleState: common.String("RUNNING"),
+		}),
+		get:     failed,
+		targets: []string{"STOPPED"},
+	})
+
+	errs := h.confirmAll(cs)
+	if len(errs) != 1 || !errors.As(errs[0], &ErrUnexpectedState{}) {
+		t.Fatalf("expected only the failed resource to error, got %v", errs)
+	}
+}
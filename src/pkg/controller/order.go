@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+)
+
+const (
+	START_ORDER_KEY string = "StartOrder" // Lower orders start first, stop last
+	DEPENDS_ON_KEY  string = "DependsOn"  // Comma separated OCIDs started first
+)
+
+// ErrDependencyCycle indicates a task is part of, or depends on, a cycle of
+// DependsOn tags
+type ErrDependencyCycle struct {
+	ID string
+}
+
+func (e ErrDependencyCycle) Error() string {
+	return fmt.Sprintf("resource %s is part of or depends on a DependsOn cycle", e.ID)
+}
+
+// ErrPrerequisite indicates a task was skipped because a task it depends on was
+// not handled successfully
+type ErrPrerequisite struct {
+	ID string
+}
+
+func (e ErrPrerequisite) Error() string {
+	return fmt.Sprintf("skipped: prerequisite %s was not handled successfully", e.ID)
+}
+
+// wave is a set of tasks handled concurrently. Tasks in a wave are only handled
+// once every task in earlier waves has finished.
+type wave []task.Task
+
+// ordering holds the tasks of a plan in the order they are executed
+type ordering struct {
+	waves   []wave
+	prereqs map[string][]string // Task ID to IDs that must succeed first
+	cyclic  []task.Task         // Tasks that cannot be ordered
+}
+
+// orderTasks arranges tasks into waves using the StartOrder and DependsOn tags
+// in namespace. OFF tasks are handled before ON tasks. Resources start in
+// ascending StartOrder after everything they depend on; resources stop in the
+// reverse order, before anything they depend on. Tasks that must finish before
+// another task are marked to wait for their target state.
+func orderTasks(tasks []task.Task, namespace string) ordering {
+	o := ordering{prereqs: make(map[string][]string)}
+
+	for _, act := range []action.Action{action.OFF, action.ON} {
+		group := make(map[string]task.Task)
+		for _, t := range tasks {
+			if t.Action == act {
+				group[*t.Resource.Identifier] = t
+			}
+		}
+		if len(group) == 0 {
+			continue
+		}
+
+		o.order(group, namespace, act == action.OFF)
+	}
+
+	return o
+}
+
+// order appends the waves for a group of tasks sharing one action
+func (o *ordering) order(group map[string]task.Task, namespace string, reverse bool) {
+	// Rank start orders so gaps between orders do not create empty waves
+	orders := make(map[string]int, len(group))
+	ranks := make([]int, 0)
+	for id, t := range group {
+		orders[id] = startOrder(t, namespace)
+		if !slices.Contains(ranks, orders[id]) {
+			ranks = append(ranks, orders[id])
+		}
+	}
+	slices.Sort(ranks)
+	if reverse {
+		slices.Reverse(ranks)
+	}
+
+	// Prerequisites within the group. Starting waits on dependencies, stopping
+	// waits on dependents.
+	for id, t := range group {
+		for _, dep := range dependsOn(t, namespace) {
+			if _, ok := group[dep]; !ok || dep == id {
+				continue
+			}
+			if reverse {
+				o.prereqs[dep] = append(o.prereqs[dep], id)
+			} else {
+				o.prereqs[id] = append(o.prereqs[id], dep)
+			}
+		}
+	}
+
+	// Level is the later of the task's ranked order and one past its prerequisites
+	levels := make(map[string]int, len(group))
+	visiting := make(map[string]bool)
+	var level func(id string) (int, bool)
+	level = func(id string) (int, bool) {
+		if l, ok := levels[id]; ok {
+			return l, l >= 0
+		}
+		if visiting[id] {
+			return 0, false
+		}
+		visiting[id] = true
+		defer delete(visiting, id)
+
+		l := slices.Index(ranks, orders[id])
+		for _, p := range o.prereqs[id] {
+			pl, ok := level(p)
+			if !ok {
+				levels[id] = -1
+				return 0, false
+			}
+			l = max(l, pl+1)
+		}
+		levels[id] = l
+
+		return l, true
+	}
+
+	// Walk tasks in a stable order so waves are deterministic
+	ids := make([]string, 0, len(group))
+	for id := range group {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	waves := make([]wave, 0)
+	for _, id := range ids {
+		l, ok := level(id)
+		if !ok {
+			o.cyclic = append(o.cyclic, group[id])
+			continue
+		}
+		for len(waves) <= l {
+			waves = append(waves, make(wave, 0))
+		}
+		waves[l] = append(waves[l], group[id])
+	}
+
+	// Tasks other tasks wait on must confirm their target state
+	for _, w := range waves {
+		for i, t := range w {
+			if o.required(*t.Resource.Identifier) {
+				w[i].Wait = true
+			}
+		}
+		if len(w) > 0 {
+			o.waves = append(o.waves, w)
+		}
+	}
+}
+
+// required returns true if another task waits on id
+func (o *ordering) required(id string) bool {
+	for _, prereqs := range o.prereqs {
+		if slices.Contains(prereqs, id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// startOrder returns the StartOrder tag of the task's resource, zero if unset or
+// invalid
+func startOrder(t task.Task, namespace string) int {
+	v, ok := t.Resource.DefinedTags[namespace][START_ORDER_KEY]
+	if !ok || v == nil {
+		return 0
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(fmt.Sprint(v)))
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
+
+// dependsOn returns the OCIDs in the DependsOn tag of the task's resource
+func dependsOn(t task.Task, namespace string) []string {
+	v, ok := t.Resource.DefinedTags[namespace][DEPENDS_ON_KEY]
+	if !ok || v == nil {
+		return nil
+	}
+
+	deps := make([]string, 0)
+	for _, d := range strings.Split(fmt.Sprint(v), ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			deps = append(deps, d)
+		}
+	}
+
+	return deps
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+func orderTask(act action.Action, id string, tags map[string]interface{}) task.Task {
+	return task.NewTask(act, rs.ResourceSummary{
+		Identifier:  common.String(id),
+		DefinedTags: map[string]map[string]interface{}{"Schedule": tags},
+	})
+}
+
+// waveIDs returns the resource IDs of each wave
+func waveIDs(o ordering) [][]string {
+	ids := make([][]string, len(o.waves))
+	for i, w := range o.waves {
+		for _, t := range w {
+			ids[i] = append(ids[i], *t.Resource.Identifier)
+		}
+	}
+
+	return ids
+}
+
+func TestOrderTasks_Waves(t *testing.T) {
+	tasks := []task.Task{
+		orderTask(action.ON, "app", map[string]interface{}{"DependsOn": "db"}),
+		orderTask(action.ON, "db", map[string]interface{}{"StartOrder": "10"}),
+		orderTask(action.ON, "web", map[string]interface{}{"StartOrder": "20"}),
+		orderTask(action.ON, "tools", nil),
+		orderTask(action.OFF, "cache", map[string]interface{}{"StartOrder": "5"}),
+		orderTask(action.OFF, "api", map[string]interface{}{
+			"StartOrder": "5", "DependsOn": "cache"}),
+	}
+
+	o := orderTasks(tasks, "Schedule")
+	got := waveIDs(o)
+	want := [][]string{
+		{"api"},        // Stop dependents first
+		{"cache"},      // Then their dependencies
+		{"tools"},      // Order 0 starts first
+		{"db"},         // Order 10
+		{"app", "web"}, // After db, alongside order 20
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got waves %v, want %v", got, want)
+	}
+	for i := range want {
+		if len(got[i]) != len(want[i]) {
+			t.Fatalf("got waves %v, want %v", got, want)
+		}
+		for j := range want[i] {
+			if got[i][j] != want[i][j] {
+				t.Fatalf("got waves %v, want %v", got, want)
+			}
+		}
+	}
+
+	// Dependencies must confirm their state before the next wave
+	for _, w := range o.waves {
+		for _, tk := range w {
+			id := *tk.Resource.Identifier
+			if wantWait := id == "db" || id == "api"; tk.Wait != wantWait {
+				t.Errorf("%s: got wait %v, want %v", id, tk.Wait, wantWait)
+			}
+		}
+	}
+}
+
+func TestOrderTasks_Cycle(t *testing.T) {
+	tasks := []task.Task{
+		orderTask(action.ON, "a", map[string]interface{}{"DependsOn": "b"}),
+		orderTask(action.ON, "b", map[string]interface{}{"DependsOn": "a"}),
+		orderTask(action.ON, "c", nil),
+	}
+
+	o := orderTasks(tasks, "Schedule")
+	if len(o.cyclic) != 2 {
+		t.Errorf("expected 2 cyclic tasks, got %d", len(o.cyclic))
+	}
+	if got := waveIDs(o); len(got) != 1 || got[0][0] != "c" {
+		t.Errorf("expected only c to be ordered, got %v", got)
+	}
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+)
+
+// Plan is the set of actions a controller decided to take in a region. Plans are
+// JSON serializable so they can be saved, reviewed, and executed later.
+type Plan struct {
+	Region  string      `json:"region"`
+	Created time.Time   `json:"created"`
+	Managed int         `json:"managed"` // Resources found by search
+	Tasks   []task.Task `json:"tasks"`
+	Blocked []task.Task `json:"blocked"` // OFF actions blocked by protection
+}
+
+func newPlan(region string, managed int) *Plan {
+	return &Plan{
+		Region:  region,
+		Created: time.Now(),
+		Managed: managed,
+		Tasks:   make([]task.Task, 0),
+		Blocked: make([]task.Task, 0),
+	}
+}
+
+// WritePlans encodes plans for one or more regions as indented JSON
+func WritePlans(w io.Writer, plans []*Plan) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(plans)
+}
+
+// ReadPlans decodes plans written by WritePlans
+func ReadPlans(r io.Reader) ([]*Plan, error) {
+	plans := make([]*Plan, 0)
+	if err := json.NewDecoder(r).Decode(&plans); err != nil {
+		return nil, err
+	}
+
+	return plans, nil
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+func TestPlans_RoundTrip(t *testing.T) {
+	p := newPlan("us-ashburn-1", 2)
+	tk := task.NewTask(action.OFF, rs.ResourceSummary{
+		Identifier:     common.String("ocid1.instance.oc1..aaaa"),
+		ResourceType:   common.String("Instance"),
+		LifecycleState: common.String("RUNNING"),
+		DefinedTags: map[string]map[string]interface{}{
+			"Schedule": {"AnyDay": "0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0"},
+		},
+	})
+	tk.Reason = "test"
+	p.Tasks = append(p.Tasks, tk)
+
+	var buf bytes.Buffer
+	if err := WritePlans(&buf, []*Plan{p}); err != nil {
+		t.Fatalf("unexpected error writing plan: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"action": "off"`)) {
+		t.Errorf("expected action encoded by name, got %s", buf.String())
+	}
+
+	plans, err := ReadPlans(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error reading plan: %v", err)
+	}
+	if len(plans) != 1 || len(plans[0].Tasks) != 1 {
+		t.Fatalf("expected 1 plan with 1 task, got %+v", plans)
+	}
+
+	got := plans[0].Tasks[0]
+	if got.Action != action.OFF || got.Reason != "test" ||
+		*got.Resource.Identifier != "ocid1.instance.oc1..aaaa" ||
+		plans[0].Region != "us-ashburn-1" || plans[0].Managed != 2 {
+		t.Errorf("plan did not round trip, got %+v", plans[0])
+	}
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+// ErrProtected indicates an OFF action was blocked by the protection policy
+type ErrProtected struct {
+	Reason string
+}
+
+func (e ErrProtected) Error() string {
+	return "protected: " + e.Reason
+}
+
+// Protection is a global safety policy checked before any resource is turned
+// off. Resources are protected by OCID, by the compartment they are in or any
+// compartment above it, or by a freeform tag. Protection never blocks turning a
+// resource on.
+type Protection struct {
+	ids          map[string]bool
+	compartments map[string]bool
+	tree         *CompartmentDefaults // Compartment hierarchy, nil for direct matches only
+	tagKey       string
+	tagValue     string // Empty matches any value of tagKey
+}
+
+// NewProtection builds a protection policy. tag is a freeform tag in key=value
+// or key form, empty disables tag protection. tree resolves compartments nested
+// within protected compartments. If compartments are protected and tree has no
+// hierarchy every resource is protected, as nesting cannot be checked.
+func NewProtection(ids, compartments []string, tag string,
+	tree *CompartmentDefaults) (*Protection, error) {
+	p := Protection{
+		ids:          make(map[string]bool, len(ids)),
+		compartments: make(map[string]bool, len(compartments)),
+		tree:         tree,
+	}
+
+	for _, id := range ids {
+		p.ids[id] = true
+	}
+
+	for _, c := range compartments {
+		p.compartments[c] = true
+	}
+
+	if tag != "" {
+		key, value, _ := strings.Cut(tag, "=")
+		p.tagKey = strings.TrimSpace(key)
+		p.tagValue = strings.TrimSpace(value)
+		if p.tagKey == "" {
+			return nil, fmt.Errorf("invalid protect tag %s", tag)
+		}
+	}
+
+	return &p, nil
+}
+
+// Protected returns the reason a resource is protected and true if it may not
+// be turned off
+func (p *Protection) Protected(item rs.ResourceSummary) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+
+	if item.Identifier != nil && p.ids[*item.Identifier] {
+		return "resource is on the protected list", true
+	}
+
+	if len(p.compartments) > 0 && !p.tree.HasHierarchy() {
+		return "compartment hierarchy unavailable, protected compartments cannot be checked", true
+	}
+
+	if item.CompartmentId != nil {
+		for c := range p.compartments {
+			if p.tree.Within(*item.CompartmentId, c) {
+				return fmt.Sprintf("compartment %s is protected", c), true
+			}
+		}
+	}
+
+	if p.tagKey != "" {
+		if v, ok := item.FreeformTags[p.tagKey]; ok &&
+			(p.tagValue == "" || strings.EqualFold(v, p.tagValue)) {
+			return fmt.Sprintf("resource has protect tag %s=%s", p.tagKey, v), true
+		}
+	}
+
+	return "", false
+}
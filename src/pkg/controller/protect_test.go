@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/id"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+func TestProtection_Protected(t *testing.T) {
+	tree := NewCompartmentDefaults([]id.Compartment{
+		{ID: "ocid1.tenancy.oc1..root", Name: "tenancy"},
+		{ID: "ocid1.compartment.oc1..shared", Name: "shared", ParentID: "ocid1.tenancy.oc1..root"},
+		{ID: "ocid1.compartment.oc1..team", Name: "team", ParentID: "ocid1.compartment.oc1..shared"},
+		{ID: "ocid1.compartment.oc1..dev", Name: "dev", ParentID: "ocid1.tenancy.oc1..root"},
+	}, "Schedule", nil)
+	p, err := NewProtection([]string{"ocid1.instance.oc1..protected"},
+		[]string{"ocid1.compartment.oc1..shared"}, "frugal:protect=true", tree)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		item rs.ResourceSummary
+		want bool
+	}{
+		{"listed id", rs.ResourceSummary{
+			Identifier: common.String("ocid1.instance.oc1..protected"),
+		}, true},
+		{"protected compartment", rs.ResourceSummary{
+			Identifier:    common.String("ocid1.instance.oc1..other"),
+			CompartmentId: common.String("ocid1.compartment.oc1..shared"),
+		}, true},
+		{"nested in protected compartment", rs.ResourceSummary{
+			Identifier:    common.String("ocid1.instance.oc1..other"),
+			CompartmentId: common.String("ocid1.compartment.oc1..team"),
+		}, true},
+		{"protect tag", rs.ResourceSummary{
+			Identifier:   common.String("ocid1.instance.oc1..other"),
+			FreeformTags: map[string]string{"frugal:protect": "TRUE"},
+		}, true},
+		{"protect tag other value", rs.ResourceSummary{
+			Identifier:   common.String("ocid1.instance.oc1..other"),
+			FreeformTags: map[string]string{"frugal:protect": "false"},
+		}, false},
+		{"unprotected", rs.ResourceSummary{
+			Identifier:    common.String("ocid1.instance.oc1..other"),
+			CompartmentId: common.String("ocid1.compartment.oc1..dev"),
+		}, false},
+	}
+
+	for _, c := range cases {
+		if _, got := p.Protected(c.item); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	// Without the hierarchy nesting cannot be checked so every OFF is blocked
+	p, err = NewProtection(nil, []string{"ocid1.compartment.oc1..shared"}, "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := p.Protected(rs.ResourceSummary{
+		Identifier:    common.String("ocid1.instance.oc1..other"),
+		CompartmentId: common.String("ocid1.compartment.oc1..dev"),
+	}); !ok {
+		t.Errorf("expected resources protected without compartment hierarchy")
+	}
+
+	if _, err := NewProtection(nil, nil, "=true", nil); err == nil {
+		t.Errorf("expected error for protect tag without key")
+	}
+}
@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/handler"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+)
+
+// RunSummary records the outcome of every task handled during a controller run
+type RunSummary struct {
+	Region  string           `json:"region"`
+	Start   time.Time        `json:"start"`
+	End     time.Time        `json:"end"`
+	Results []ResourceResult `json:"results"`
+	mu      sync.Mutex
+}
+
+// ResourceResult is the outcome of a single handled task
+type ResourceResult struct {
+	ID     string        `json:"id"`
+	Type   string        `json:"type"`
+	Action action.Action `json:"action"`
+	Result task.Result   `json:"result"`
+	Error  string        `json:"error,omitempty"`
+
+	unfinished bool // Replayed by the next run
+}
+
+func newRunSummary(region string) *RunSummary {
+	return &RunSummary{
+		Region:  region,
+		Start:   time.Now(),
+		Results: make([]ResourceResult, 0),
+	}
+}
+
+// record classifies the error returned from handling t and stores the result
+func (s *RunSummary) record(t task.Task, err error) task.Result {
+	r := ResourceResult{
+		ID:     *t.Resource.Identifier,
+		Type:   *t.Resource.ResourceType,
+		Action: t.Action,
+		Result: resultOf(err),
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	r.unfinished = r.Result == task.FAILED || r.Result == task.TIMED_OUT ||
+		r.Result == task.ABORTED || errors.As(err, &ErrPrerequisite{})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Results = append(s.Results, r)
+
+	return r.Result
+}
+
+// Counts returns the number of tasks per result
+func (s *RunSummary) Counts() map[task.Result]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[task.Result]int)
+	for _, r := range s.Results {
+		counts[r.Result]++
+	}
+
+	return counts
+}
+
+// Unfinished returns the OCIDs of resources that failed, timed out, or were
+// aborted or skipped for a failed prerequisite, so their transitions are replayed
+func (s *RunSummary) Unfinished() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0)
+	for _, r := range s.Results {
+		if r.unfinished {
+			ids = append(ids, r.ID)
+		}
+	}
+
+	return ids
+}
+
+// finish marks the run complete and logs the summary
+func (s *RunSummary) finish(log *slog.Logger) {
+	s.End = time.Now()
+	counts := s.Counts()
+
+	log.Info("Run summary",
+		slog.String("Region", s.Region),
+		slog.Int("Handled", len(s.Results)),
+		slog.Int(string(task.SUCCEEDED), counts[task.SUCCEEDED]),
+		slog.Int(string(task.FAILED), counts[task.FAILED]),
+		slog.Int(string(task.TIMED_OUT), counts[task.TIMED_OUT]),
+		slog.Int(string(task.SKIPPED), counts[task.SKIPPED]),
+		slog.Int(string(task.BLOCKED), counts[task.BLOCKED]),
+		slog.Int(string(task.ABORTED), counts[task.ABORTED]),
+		slog.Int(string(task.PLANNED), counts[task.PLANNED]),
+		slog.Duration("Duration", s.End.Sub(s.Start)))
+
+	for _, r := range s.Results {
+		if r.Result == task.SKIPPED || r.Result == task.PLANNED {
+			log.Info("Resource not handled",
+				slog.String("ID", r.ID),
+				slog.String("Type", r.Type),
+				slog.String("Result", string(r.Result)),
+				slog.String("Reason", r.Error))
+		} else if r.Result != task.SUCCEEDED {
+			log.Warn("Unsuccessful resource",
+				slog.String("ID", r.ID),
+				slog.String("Type", r.Type),
+				slog.String("Result", string(r.Result)),
+				slog.String("error", r.Error))
+		}
+	}
+}
+
+// resultOf maps a handler error to a task result
+func resultOf(err error) task.Result {
+	var blast ErrBlastRadius
+	switch {
+	case err == nil:
+		return task.SUCCEEDED
+	case errors.Is(err, handler.ErrWaitTimeout):
+		return task.TIMED_OUT
+	case errors.As(err, &handler.ErrSkipped{}), errors.As(err, &ErrPrerequisite{}):
+		return task.SKIPPED
+	case errors.As(err, &ErrProtected{}):
+		return task.BLOCKED
+	case errors.Is(err, ErrRunCancelled):
+		return task.ABORTED
+	case errors.As(err, &blast):
+		if blast.DryRun {
+			return task.PLANNED
+		}
+		return task.ABORTED
+	default:
+		return task.FAILED
+	}
+}
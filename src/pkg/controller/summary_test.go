@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/handler"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
+)
+
+func TestRunSummary_UnfinishedPartialFailure(t *testing.T) {
+	s := newRunSummary("us-ashburn-1")
+	record := func(id string, err error) {
+		s.record(task.NewTask(action.OFF, rs.ResourceSummary{
+			Identifier:   common.String(id),
+			ResourceType: common.String("Instance"),
+		}), err)
+	}
+
+	record("succeeded", nil)
+	record("failed", errors.New("service error"))
+	record("timed-out", handler.ErrWaitTimeout)
+	record("skipped", handler.ErrSkipped{Reason: "pool member"})
+	record("dependent", ErrPrerequisite{ID: "failed"})
+	record("aborted", ErrBlastRadius{Reason: "too many"})
+	record("planned", ErrBlastRadius{Reason: "too many", DryRun: true})
+	record("blocked", ErrProtected{Reason: "protected"})
+
+	got := s.Unfinished()
+	slices.Sort(got)
+	want := []string{"aborted", "dependent", "failed", "timed-out"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected unfinished %v, got %v", want, got)
+	}
+}
@@ -2,7 +2,9 @@ package controller
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,11 +17,20 @@ import (
 )
 
 const (
-	QUERY         string = "query instance, dbsystem, autonomousdatabase, analyticsinstance, integrationinstance resources"
-	TC_WORK_QUEUE uint8  = 8
-	TC_TIMEOUT           = 5 * time.Second
+	TC_WORK_QUEUE uint8 = 8
+	TC_TIMEOUT          = 5 * time.Second
+
+	// Tag key marking a resource whose schedule keys define a group
+	GROUP_SCHEDULE_KEY string = "GroupSchedule"
 )
 
+// Query returns the structured search query for resources of the search types.
+// Additional fields are returned so handlers can inspect details such as shape.
+func Query(types []string) string {
+	return fmt.Sprintf("query %s resources return allAdditionalFields",
+		strings.Join(types, ", "))
+}
+
 // TagController keeps track of all clients and scheduler interface for managing
 // access, decisions, and actions on resources. Uses tags to manage schedules.
 type TagController struct {
@@ -28,7 +39,16 @@ type TagController struct {
 	scheduler    scheduler.Scheduler
 	action       action.Action
 	handler      handler.Handler
+	protection   *Protection
+	blast        BlastRadius
+	defaults     *CompartmentDefaults
+	since        time.Time
+	until        time.Time
+	retry        map[string]time.Time // Since per resource OCID replaying unfinished runs
+	compartment  string
 	search       rs.ResourceSearchClient
+	summary      *RunSummary
+	ctx          context.Context // Stops handling tasks when done, nil never stops
 	log          *slog.Logger
 }
 
@@ -48,7 +68,33 @@ func NewTagController(opts ControllerOpts) (*TagController, error) {
 	handlerOpts := handler.HandlerOpts{
 		ConfigProvider: opts.ConfigurationProvider,
 		Logger:         opts.LogFunc("Component", "Handler"),
+		TagNamespace:   opts.TagNamespace,
+		WaitForState:   &opts.WaitForState,
+	}
+	if opts.WaitTimeout > 0 {
+		handlerOpts.WaitTimeout = &opts.WaitTimeout
+	}
+	if opts.StopPolicy != "" {
+		handlerOpts.StopPolicy = &opts.StopPolicy
+	}
+	if opts.StopGrace > 0 {
+		handlerOpts.StopGrace = &opts.StopGrace
+	}
+	if opts.MysqlShutdown != "" {
+		handlerOpts.MysqlShutdown = &opts.MysqlShutdown
+	}
+	if opts.ExadataPolicy != "" {
+		handlerOpts.ExadataPolicy = &opts.ExadataPolicy
+	}
+
+	p, err := NewProtection(opts.ProtectedIDs, opts.ProtectedCompartments,
+		opts.ProtectTag, opts.CompartmentDefaults)
+	if err != nil {
+		return nil, err
 	}
+	c.protection = p
+	c.blast = opts.BlastRadius
+	c.defaults = opts.CompartmentDefaults
 
 	h, err := handler.NewResourceHandler(handlerOpts)
 	if err != nil {
@@ -144,97 +190,423 @@ func (tc *TagController) Search(query string) (rs.ResourceSummaryCollection, err
 }
 
 func (tc *TagController) SetRegion(region string) {
+	tc.region = region
 	tc.search.SetRegion(region)
 	tc.handler.SetRegion(region)
 }
 
-// Run starts the controller spawning workers and queuing tasks
+// SearchTypes returns the resource search types the controller acts on
+func (tc *TagController) SearchTypes() []string {
+	return tc.handler.SearchTypes()
+}
+
+// Region returns the region the controller acts in
+func (tc *TagController) Region() string {
+	return tc.region
+}
+
+// SetPeriod makes the controller act on schedule transitions after since up to
+// until instead of the schedule at the current time. A zero until is the time of
+// evaluation.
+func (tc *TagController) SetPeriod(since, until time.Time) {
+	tc.since = since
+	tc.until = until
+}
+
+// SetRetry replays transitions of resources by OCID from an earlier time than the
+// period, as when a previous run did not finish them
+func (tc *TagController) SetRetry(retry map[string]time.Time) {
+	tc.retry = retry
+}
+
+// SetCompartment limits the controller to resources in a compartment and beneath
+// it, empty for all resources
+func (tc *TagController) SetCompartment(compartmentID string) {
+	tc.compartment = compartmentID
+}
+
+// SetContext stops a run handling further tasks once ctx is done, as when the run
+// lock is lost. Tasks already handed to a worker are finished.
+func (tc *TagController) SetContext(ctx context.Context) {
+	tc.ctx = ctx
+}
+
+// cancelled returns the cause of the run's context being done or nil
+func (tc *TagController) cancelled() error {
+	if tc.ctx == nil {
+		return nil
+	}
+
+	return context.Cause(tc.ctx)
+}
+
+// Summary returns the summary of the most recent run or nil if never run
+func (tc *TagController) Summary() *RunSummary {
+	return tc.summary
+}
+
+// Run plans actions for every resource then executes the plan
 func (tc *TagController) Run(controlWg *sync.WaitGroup) {
 	defer controlWg.Done()
 	tc.log.Info("Beginning TagController Run")
 
-	// Search for supported resource types
-	collection, err := tc.Search(QUERY)
+	plan, err := tc.Plan()
 	if err != nil {
-		tc.log.Error("error searching for resources",
+		tc.log.Error("error planning run",
 			slog.String("error", err.Error()))
 		return
 	}
+
+	tc.Execute(plan)
+}
+
+// Plan searches for resources and evaluates their schedules, returning the
+// actions to take without taking any. OFF actions on protected resources are
+// returned as blocked.
+func (tc *TagController) Plan() (*Plan, error) {
+	// Search for supported resource types
+	collection, err := tc.Search(Query(tc.SearchTypes()))
+	if err != nil {
+		return nil, fmt.Errorf("error searching for resources: %w", err)
+	}
 	tc.log.Debug("items received from search",
 		slog.Int("count", len(collection.Items)))
 
-	// Make control objects, taskschannel for resources and WaitGroup to sync workers
-	// with controller
-	resources := make(chan rs.ResourceSummary, TC_WORK_QUEUE)
+	// Groups are defined before scoping as designated resources may be elsewhere
+	tc.defineGroups(collection.Items)
+	items := tc.scope(collection.Items)
+
+	plan := newPlan(tc.region, len(items))
+	for _, item := range items {
+		t, ok := tc.evaluate(item)
+		if !ok {
+			continue
+		}
+
+		// Protection is enforced here so no scheduler can bypass it
+		if reason, ok := tc.protected(t); ok {
+			t.Reason = reason
+			plan.Blocked = append(plan.Blocked, t)
+			continue
+		}
+
+		plan.Tasks = append(plan.Tasks, t)
+	}
+
+	tc.log.Info("Planned run",
+		slog.Int("Tasks", len(plan.Tasks)),
+		slog.Int("Blocked", len(plan.Blocked)))
+
+	return plan, nil
+}
+
+// scope returns the items within the controller's compartment
+func (tc *TagController) scope(items []rs.ResourceSummary) []rs.ResourceSummary {
+	if tc.compartment == "" {
+		return items
+	}
+
+	scoped := make([]rs.ResourceSummary, 0, len(items))
+	for _, item := range items {
+		if item.CompartmentId != nil &&
+			tc.defaults.Within(*item.CompartmentId, tc.compartment) {
+			scoped = append(scoped, item)
+		}
+	}
+	tc.log.Debug("items within compartment",
+		slog.String("Compartment", tc.compartment),
+		slog.Int("count", len(scoped)))
+
+	return scoped
+}
+
+// Execute handles every task in plan in waves ordered by StartOrder and
+// DependsOn tags. Protection and the blast radius are checked again so an
+// edited plan cannot bypass them.
+func (tc *TagController) Execute(plan *Plan) {
+	tc.summary = newRunSummary(tc.region)
+	defer tc.summary.finish(tc.log)
+
+	for _, t := range plan.Blocked {
+		tc.summary.record(t, ErrProtected{Reason: t.Reason})
+	}
+
+	tasks := make([]task.Task, 0, len(plan.Tasks))
+	for _, t := range plan.Tasks {
+		if reason, ok := tc.protected(t); ok {
+			tc.summary.record(t, ErrProtected{Reason: reason})
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+
+	if reason, ok := tc.blast.exceeded(tasks, plan.Managed, tc.handler.Actionable); ok {
+		tc.abort(tasks, reason)
+		return
+	}
+
+	order := orderTasks(tasks, tc.tagNamespace)
+	for _, t := range order.cyclic {
+		tc.summary.record(t, ErrDependencyCycle{ID: *t.Resource.Identifier})
+	}
+
+	// Tasks not handled successfully, their dependents are skipped
+	var failed sync.Map
+	for _, w := range order.waves {
+		tc.runWave(w, order.prereqs, &failed)
+	}
+
+	// Graceful stops may still be escalating
+	tc.handler.Wait()
+}
+
+// runWave spawns workers to handle every task in a wave and waits for them to
+// finish. Tasks whose prerequisites were not handled successfully are skipped.
+func (tc *TagController) runWave(w wave, prereqs map[string][]string,
+	failed *sync.Map) {
+	// Make control objects, tasks channel for workers and WaitGroup to sync
+	// workers with controller
+	queue := make(chan task.Task, TC_WORK_QUEUE)
 	var workerWg sync.WaitGroup
 
 	// Create workers
 	for i := range TC_WORK_QUEUE {
-		go tc.worker(i, resources, &workerWg)
+		go tc.worker(i, queue, &workerWg, failed)
 		workerWg.Add(1)
 	}
 
-	// Add items to tasks queue
-	for _, item := range collection.Items {
-		resources <- item
+	// Add tasks to queue
+	for _, t := range w {
+		id := *t.Resource.Identifier
+		if cause := tc.cancelled(); cause != nil {
+			tc.summary.record(t, fmt.Errorf("%w: %w", ErrRunCancelled, cause))
+			failed.Store(id, true)
+			continue
+		}
+		if p := failedPrereq(prereqs[id], failed); p != "" {
+			err := ErrPrerequisite{ID: p}
+			tc.summary.record(t, err)
+			failed.Store(id, true)
+			tc.log.Info("Resource skipped",
+				slog.String("Identifier", id),
+				slog.String("Reason", err.Error()))
+			continue
+		}
+		queue <- t
 	}
 
-	// Send close signal to workers once out of items and wait for workers to finish
-	close(resources)
+	// Send close signal to workers once out of tasks and wait for workers to finish
+	close(queue)
 	workerWg.Wait()
 }
 
-// worker does the work of taking resources, creating tasks, and calling handlers
-func (tc *TagController) worker(id uint8, resources <-chan rs.ResourceSummary,
-	wg *sync.WaitGroup) {
-	defer wg.Done()
+// failedPrereq returns the first of prereqs that failed or empty if none did
+func failedPrereq(prereqs []string, failed *sync.Map) string {
+	for _, p := range prereqs {
+		if _, ok := failed.Load(p); ok {
+			return p
+		}
+	}
 
-	// Log attribute to identify worker
-	logGroup := slog.Group("Worker",
-		slog.Int("ID", int(id)))
-	tc.log.Debug("Started Worker", logGroup)
+	return ""
+}
 
-	for {
-		if item, more := <-resources; more {
-			itemGroup := slog.Group("Resource", logGroup,
-				slog.String("Identifier", *item.Identifier),
-				slog.String("Type", *item.ResourceType))
-
-			activeSchedule, err := tc.scheduler.ActiveSchedule(
-				item.DefinedTags[tc.tagNamespace])
-			if err != nil {
-				tc.log.Error("error problem reading active schedule",
-					"error", err,
-					"tags", item.DefinedTags[tc.tagNamespace])
-				continue
-			}
+// defineGroups registers the schedule of every resource designated to define a
+// group with the scheduler. Designated resources override groups of the same name
+// from the schedule file.
+func (tc *TagController) defineGroups(items []rs.ResourceSummary) {
+	g, ok := tc.scheduler.(scheduler.Grouper)
+	if !ok {
+		return
+	}
 
-			tc.log.Info("Handling Resource", itemGroup,
-				slog.String("active schedule", activeSchedule))
+	for _, item := range items {
+		tags := item.DefinedTags[tc.tagNamespace]
+		v, ok := tags[GROUP_SCHEDULE_KEY]
+		if !ok || v == nil || strings.TrimSpace(fmt.Sprint(v)) == "" {
+			continue
+		}
+		name := strings.TrimSpace(fmt.Sprint(v))
 
-			act, err := tc.scheduler.Evaluate(activeSchedule)
-			if err != nil {
-				tc.log.Warn("error evaluating resource", itemGroup,
-					"error", err)
+		keys := make(map[string]string, len(tags))
+		for k, v := range tags {
+			if k != GROUP_SCHEDULE_KEY && v != nil {
+				keys[k] = strings.TrimSpace(fmt.Sprint(v))
 			}
+		}
 
-			// If controller action and scheduler action are not compatible, skip
-			if !action.Compare(tc.action, act) {
-				tc.log.Info("No action required", itemGroup,
-					slog.Any("Controller Action", tc.action))
-				continue
-			}
+		g.SetGroup(name, keys)
+		tc.log.Info("Group schedule defined by resource",
+			slog.String("Group", name),
+			slog.String("Identifier", *item.Identifier))
+	}
+}
 
-			err = tc.handler.HandleResource(task.NewTask(act, item))
-			if err != nil {
-				tc.log.Error("error handling resource",
-					itemGroup,
-					"error", err)
+// evaluate reads the active schedule of a resource and returns a task with the
+// reason for its action if the resource requires one
+func (tc *TagController) evaluate(item rs.ResourceSummary) (task.Task, bool) {
+	itemGroup := slog.Group("Resource",
+		slog.String("Identifier", *item.Identifier),
+		slog.String("Type", *item.ResourceType))
+
+	// Resources without a schedule inherit their compartment's default
+	tags := item.DefinedTags[tc.tagNamespace]
+	source := ""
+	if !hasSchedule(tags) && item.CompartmentId != nil {
+		if keys, name, ok := tc.defaults.Resolve(*item.CompartmentId); ok {
+			merged := make(map[string]interface{}, len(keys)+len(tags))
+			for k, v := range keys {
+				merged[k] = v
 			}
+			for k, v := range tags {
+				merged[k] = v
+			}
+			tags = merged
+			source = name
 
+			tc.log.Info("Using compartment default schedule", itemGroup,
+				slog.String("Compartment", name))
+		}
+	}
+
+	activeSchedule, err := tc.scheduler.ActiveSchedule(tags)
+	if err != nil {
+		tc.log.Error("error problem reading active schedule",
+			"error", err,
+			"tags", tags)
+		return task.Task{}, false
+	}
+
+	tc.log.Info("Evaluating Resource", itemGroup,
+		slog.String("active schedule", activeSchedule))
+
+	since := tc.sinceOf(*item.Identifier)
+	act, err := tc.transition(tags, activeSchedule, since)
+	if err != nil {
+		tc.log.Warn("error evaluating resource", itemGroup,
+			"error", err)
+	}
+
+	// If controller action and scheduler action are not compatible, skip
+	if !action.Compare(tc.action, act) {
+		tc.log.Info("No action required", itemGroup,
+			slog.Any("Controller Action", tc.action))
+		return task.Task{}, false
+	}
+
+	t := task.NewTask(act, item)
+	t.Reason = fmt.Sprintf("active schedule %q evaluates to %s", activeSchedule, act)
+	if !since.IsZero() {
+		t.Reason = fmt.Sprintf("active schedule %q transitions to %s since %s",
+			activeSchedule, act, since.Format(time.RFC3339))
+	}
+	if source != "" {
+		t.Reason += fmt.Sprintf(" (default of compartment %s)", source)
+	}
+
+	return t, true
+}
+
+// sinceOf returns the start of the period transitions of a resource are
+// evaluated over
+func (tc *TagController) sinceOf(id string) time.Time {
+	if t, ok := tc.retry[id]; ok {
+		return t
+	}
+
+	return tc.since
+}
+
+// transition evaluates tags for schedule changes after since when set, otherwise
+// evaluates the active schedule at the current time
+func (tc *TagController) transition(tags any, activeSchedule string,
+	since time.Time) (action.Action, error) {
+	tr, ok := tc.scheduler.(scheduler.Transitioner)
+	if since.IsZero() || !ok {
+		return tc.scheduler.Evaluate(activeSchedule)
+	}
+
+	until := tc.until
+	if until.IsZero() {
+		until = time.Now()
+	}
+
+	return tr.Transition(tags, since, until)
+}
+
+// protected returns the reason and true if the protection policy blocks t
+func (tc *TagController) protected(t task.Task) (string, bool) {
+	if t.Action != action.OFF {
+		return "", false
+	}
+
+	reason, ok := tc.protection.Protected(t.Resource)
+	if ok {
+		tc.log.Warn("Resource protected, action blocked",
+			slog.String("Identifier", *t.Resource.Identifier),
+			slog.String("Type", *t.Resource.ResourceType),
+			slog.String("Reason", reason))
+	}
+
+	return reason, ok
+}
+
+// abort reports every planned task without handling any of them
+func (tc *TagController) abort(tasks []task.Task, reason string) {
+	if tc.blast.DryRun {
+		tc.log.Warn("Blast radius exceeded, switching to dry run",
+			slog.String("Reason", reason))
+	} else {
+		tc.log.Error("Blast radius exceeded, aborting run",
+			slog.String("Reason", reason))
+	}
+
+	for _, t := range tasks {
+		tc.log.Info("Planned action",
+			slog.String("Identifier", *t.Resource.Identifier),
+			slog.String("Type", *t.Resource.ResourceType),
+			slog.Any("Action", t.Action),
+			slog.String("Reason", t.Reason))
+		tc.summary.record(t, ErrBlastRadius{Reason: reason, DryRun: tc.blast.DryRun})
+	}
+}
+
+// worker does the work of taking tasks and calling handlers
+func (tc *TagController) worker(id uint8, tasks <-chan task.Task,
+	wg *sync.WaitGroup, failed *sync.Map) {
+	defer wg.Done()
+
+	// Log attribute to identify worker
+	logGroup := slog.Group("Worker",
+		slog.Int("ID", int(id)))
+	tc.log.Debug("Started Worker", logGroup)
+
+	for t := range tasks {
+		itemGroup := slog.Group("Resource", logGroup,
+			slog.String("Identifier", *t.Resource.Identifier),
+			slog.String("Type", *t.Resource.ResourceType))
+
+		tc.log.Info("Handling Resource", itemGroup,
+			slog.Any("Action", t.Action))
+
+		err := tc.handler.HandleResource(t)
+		result := tc.summary.record(t, err)
+		if result != task.SUCCEEDED {
+			failed.Store(*t.Resource.Identifier, true)
+		}
+		if result == task.SKIPPED {
+			tc.log.Info("Resource skipped", itemGroup,
+				slog.String("Reason", err.Error()))
+		} else if err != nil {
+			tc.log.Error("error handling resource",
+				itemGroup,
+				slog.String("Result", string(result)),
+				"error", err)
 		} else {
-			tc.log.Debug("Work finished", logGroup)
-			return
+			tc.log.Debug("Resource handled", itemGroup,
+				slog.String("Result", string(result)))
 		}
 	}
+
+	tc.log.Debug("Work finished", logGroup)
 }
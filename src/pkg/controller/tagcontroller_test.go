@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/action"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller/task"
+	"github.com/oracle/oci-go-sdk/v65/common"
+)
+
+// fakeHandler records the resources it handles in order and those it was asked
+// to wait on
+type fakeHandler struct {
+	mu      sync.Mutex
+	handled []string
+	waited  []string
+}
+
+func (h *fakeHandler) HandleResource(t task.Task) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.handled = append(h.handled, *t.Resource.Identifier)
+	if t.Wait {
+		h.waited = append(h.waited, *t.Resource.Identifier)
+	}
+	return nil
+}
+
+func (h *fakeHandler) SetRegion(string)          {}
+func (h *fakeHandler) Actionable(task.Task) bool { return true }
+func (h *fakeHandler) SearchTypes() []string     { return nil }
+func (h *fakeHandler) Wait()                     {}
+
+// instanceTask returns a task on an instance with schedule tags
+func instanceTask(act action.Action, id string, tags map[string]interface{}) task.Task {
+	t := orderTask(act, id, tags)
+	t.Resource.ResourceType = common.String("Instance")
+	return t
+}
+
+func testTagController(h *fakeHandler) *TagController {
+	protection, _ := NewProtection(nil, nil, "", nil)
+	return &TagController{
+		tagNamespace: "Schedule",
+		region:       "us-ashburn-1",
+		handler:      h,
+		protection:   protection,
+		log:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+func TestTagController_ExecuteCancelled(t *testing.T) {
+	h := &fakeHandler{}
+	tc := testTagController(h)
+
+	errLost := errors.New("run lock lost")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errLost)
+	tc.SetContext(ctx)
+
+	tc.Execute(&Plan{Tasks: []task.Task{
+		instanceTask(action.OFF, "ocid1.instance.oc1..a", nil),
+		instanceTask(action.OFF, "ocid1.instance.oc1..b", nil),
+	}})
+
+	if len(h.handled) != 0 {
+		t.Fatalf("expected no resources handled, got %v", h.handled)
+	}
+	for _, r := range tc.Summary().Results {
+		if r.Result != task.ABORTED {
+			t.Errorf("%s: expected aborted, got %s", r.ID, r.Result)
+		}
+	}
+	if n := len(tc.Summary().Unfinished()); n != 2 {
+		t.Fatalf("expected cancelled tasks to be replayed, got %d unfinished", n)
+	}
+}
+
+func TestTagController_ExecuteWaitsOnPrerequisites(t *testing.T) {
+	h := &fakeHandler{}
+	tc := testTagController(h)
+
+	// The database is already starting, the app must still wait for it
+	db := instanceTask(action.ON, "db", nil)
+	db.Resource.LifecycleState = common.String("STARTING")
+	app := instanceTask(action.ON, "app", map[string]interface{}{DEPENDS_ON_KEY: "db"})
+
+	tc.Execute(&Plan{Tasks: []task.Task{app, db}})
+
+	if want := []string{"db", "app"}; !slices.Equal(h.handled, want) {
+		t.Fatalf("expected %v handled in order, got %v", want, h.handled)
+	}
+	if want := []string{"db"}; !slices.Equal(h.waited, want) {
+		t.Fatalf("expected handler to wait on %v, got %v", want, h.waited)
+	}
+}
@@ -5,9 +5,24 @@ import (
 	rs "github.com/oracle/oci-go-sdk/v65/resourcesearch"
 )
 
+const (
+	SUCCEEDED Result = "succeeded"
+	FAILED    Result = "failed"
+	TIMED_OUT Result = "timed-out"
+	SKIPPED   Result = "skipped"
+	BLOCKED   Result = "blocked" // Stopped by protection policy
+	ABORTED   Result = "aborted" // Run exceeded blast radius or was cancelled
+	PLANNED   Result = "planned" // Dry run, not handled
+)
+
+// Result is the outcome of handling a task
+type Result string
+
 type Task struct {
-	Action   action.Action
-	Resource rs.ResourceSummary
+	Action   action.Action      `json:"action"`
+	Resource rs.ResourceSummary `json:"resource"`
+	Reason   string             `json:"reason,omitempty"` // Why the action was planned
+	Wait     bool               `json:"wait,omitempty"`   // Confirm target state before returning
 }
 
 func NewTask(act action.Action, item rs.ResourceSummary) Task {
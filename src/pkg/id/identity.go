@@ -138,3 +138,62 @@ func (id *Identity) UpdateNs(nsid *string, tags map[string]map[string]any) (
 
 	return response, nil
 }
+
+// Compartment is a compartment with the fields needed to inherit settings
+type Compartment struct {
+	ID          string
+	Name        string
+	ParentID    string // Empty for the root compartment
+	DefinedTags map[string]map[string]interface{}
+}
+
+// GetCompartments returns the root compartment and every active compartment
+// beneath it
+func (id *Identity) GetCompartments() ([]Compartment, error) {
+	root, err := id.GetCompartment(context.Background(),
+		identity.GetCompartmentRequest{CompartmentId: common.String(id.tenantId)})
+	if err != nil {
+		return nil, err
+	}
+
+	compartments := []Compartment{{
+		ID:          id.tenantId,
+		Name:        *root.Name,
+		DefinedTags: root.DefinedTags,
+	}}
+
+	request := identity.ListCompartmentsRequest{
+		CompartmentId:          common.String(id.tenantId),
+		CompartmentIdInSubtree: common.Bool(true),
+		AccessLevel:            identity.ListCompartmentsAccessLevelAny,
+		LifecycleState:         identity.CompartmentLifecycleStateActive,
+		Limit:                  common.Int(1000),
+	}
+
+	// Pagination by breaking when no next page
+	for {
+		response, err := id.ListCompartments(context.Background(), request)
+		if err != nil {
+			return nil, err
+		} else if response.RawResponse.StatusCode < 200 ||
+			response.RawResponse.StatusCode > 299 {
+			return nil, ErrNo2xxStatus
+		}
+
+		for _, c := range response.Items {
+			compartments = append(compartments, Compartment{
+				ID:          *c.Id,
+				Name:        *c.Name,
+				ParentID:    *c.CompartmentId,
+				DefinedTags: c.DefinedTags,
+			})
+		}
+
+		if response.OpcNextPage == nil {
+			break
+		}
+		request.Page = response.OpcNextPage
+	}
+
+	return compartments, nil
+}
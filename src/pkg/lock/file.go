@@ -0,0 +1,30 @@
+package lock
+
+import (
+	"github.com/gofrs/flock"
+)
+
+// FileLock is a lock on a local file. The operating system releases the lock
+// when the holding process exits, including when it crashes.
+type FileLock struct {
+	flock *flock.Flock
+}
+
+func NewFileLock(path string) *FileLock {
+	return &FileLock{flock: flock.New(path)}
+}
+
+// TryLock acquires the file lock without waiting
+func (l *FileLock) TryLock() (bool, error) {
+	return l.flock.TryLock()
+}
+
+// Unlock releases the file lock
+func (l *FileLock) Unlock() error {
+	return l.flock.Unlock()
+}
+
+// Lost returns nil as the file lock is held until released or the process exits
+func (l *FileLock) Lost() <-chan struct{} {
+	return nil
+}
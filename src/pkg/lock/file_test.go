@@ -0,0 +1,32 @@
+package lock
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFileLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "frugal.lock")
+	a, b := NewFileLock(path), NewFileLock(path)
+
+	ok, err := a.TryLock()
+	if err != nil || !ok {
+		t.Fatalf("expected first instance to lock, got %v, %v", ok, err)
+	}
+
+	// Other instances idle while the lock is held
+	ok, err = b.TryLock()
+	if err != nil || ok {
+		t.Fatalf("expected second instance not to lock, got %v, %v", ok, err)
+	}
+
+	if err := a.Unlock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ok, err = b.TryLock()
+	if err != nil || !ok {
+		t.Fatalf("expected second instance to lock after release, got %v, %v", ok, err)
+	}
+	b.Unlock()
+}
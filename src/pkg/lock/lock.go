@@ -0,0 +1,34 @@
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	ErrLost error = errors.New("run lock lost")
+)
+
+// Lock is held by at most one Frugal instance at a time so that only one acts on
+// resources. Implementations free the lock if its holder crashes.
+type Lock interface {
+	// TryLock acquires the lock without waiting, returning false if another
+	// instance holds it
+	TryLock() (bool, error)
+	// Unlock releases the lock if held
+	Unlock() error
+	// Lost returns a channel closed if the held lock is lost before Unlock, nil
+	// if the lock cannot be lost while its holder runs
+	Lost() <-chan struct{}
+}
+
+// holderID identifies this instance as a lock holder
+func holderID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
@@ -0,0 +1,251 @@
+package lock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+)
+
+const (
+	// Object holding the lock lease
+	LOCK_OBJECT string = "frugal/lock"
+)
+
+// lease is the content of the lock object
+type lease struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+}
+
+// objectStorageClient is the part of objectstorage.ObjectStorageClient used to
+// keep the lease
+type objectStorageClient interface {
+	GetObject(context.Context, objectstorage.GetObjectRequest) (objectstorage.GetObjectResponse, error)
+	PutObject(context.Context, objectstorage.PutObjectRequest) (objectstorage.PutObjectResponse, error)
+	DeleteObject(context.Context, objectstorage.DeleteObjectRequest) (objectstorage.DeleteObjectResponse, error)
+}
+
+// ObjectLock is a lease kept as an object in an Object Storage bucket so that
+// instances on different hosts share the lock. The holder renews the lease while
+// it runs. A crashed holder stops renewing and its lease expires after ttl.
+// Conditional requests on the object's ETag keep two instances from taking the
+// lease at once. A lease that cannot be renewed before it expires, or that
+// another instance has taken, is reported as lost.
+type ObjectLock struct {
+	client    objectStorageClient
+	namespace string
+	bucket    string
+	holder    string
+	ttl       time.Duration
+	log       *slog.Logger
+
+	mu      sync.Mutex
+	etag    string        // ETag of the held lease, empty if not held
+	expires time.Time     // Expiry of the held lease
+	stop    chan struct{} // Stops renewal
+	lost    chan struct{} // Closed if the held lease is lost
+}
+
+func NewObjectLock(cfg common.ConfigurationProvider, bucket string, ttl time.Duration,
+	log *slog.Logger) (*ObjectLock, error) {
+	client, err := objectstorage.NewObjectStorageClientWithConfigurationProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.GetNamespace(context.Background(),
+		objectstorage.GetNamespaceRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ObjectLock{
+		client:    &client,
+		namespace: *resp.Value,
+		bucket:    bucket,
+		holder:    holderID(),
+		ttl:       ttl,
+		log:       log,
+	}, nil
+}
+
+// TryLock takes the lease if there is none or it has expired
+func (l *ObjectLock) TryLock() (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.etag != "" {
+		return true, nil
+	}
+
+	resp, err := l.client.GetObject(context.Background(), objectstorage.GetObjectRequest{
+		NamespaceName: common.String(l.namespace),
+		BucketName:    common.String(l.bucket),
+		ObjectName:    common.String(LOCK_OBJECT),
+	})
+
+	var cond func(*objectstorage.PutObjectRequest)
+	switch {
+	case statusOf(err) == http.StatusNotFound:
+		// No lease, create it only if no other instance does first
+		cond = func(r *objectstorage.PutObjectRequest) { r.IfNoneMatch = common.String("*") }
+	case err != nil:
+		return false, err
+	default:
+		var current lease
+		err := json.NewDecoder(resp.Content).Decode(&current)
+		resp.Content.Close()
+		if err != nil {
+			return false, err
+		}
+
+		if current.Holder != l.holder && time.Now().Before(current.Expires) {
+			return false, nil
+		}
+
+		// Expired lease, replace it only if unchanged since read
+		cond = func(r *objectstorage.PutObjectRequest) { r.IfMatch = resp.ETag }
+	}
+
+	etag, err := l.put(cond)
+	if s := statusOf(err); s == http.StatusPreconditionFailed || s == http.StatusConflict {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	l.etag = etag
+	l.stop = make(chan struct{})
+	l.lost = make(chan struct{})
+	go l.renew(l.stop)
+
+	return true, nil
+}
+
+// Unlock stops renewal and deletes the lease
+func (l *ObjectLock) Unlock() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.etag == "" {
+		return nil
+	}
+	close(l.stop)
+
+	_, err := l.client.DeleteObject(context.Background(), objectstorage.DeleteObjectRequest{
+		NamespaceName: common.String(l.namespace),
+		BucketName:    common.String(l.bucket),
+		ObjectName:    common.String(LOCK_OBJECT),
+		IfMatch:       common.String(l.etag),
+	})
+	l.etag = ""
+
+	// Lease already expired and taken or removed
+	if s := statusOf(err); s == http.StatusPreconditionFailed || s == http.StatusNotFound {
+		return nil
+	}
+
+	return err
+}
+
+// Lost returns a channel closed if the lease is lost while held, nil if the lease
+// was never taken
+func (l *ObjectLock) Lost() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.lost
+}
+
+// renew extends the lease every third of its ttl until stopped. Failed renewals
+// are retried until the lease expires.
+func (l *ObjectLock) renew(stop <-chan struct{}) {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if !l.extend() {
+				return
+			}
+		}
+	}
+}
+
+// extend renews the held lease, returning false if the lease is lost
+func (l *ObjectLock) extend() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	etag, err := l.put(func(r *objectstorage.PutObjectRequest) {
+		r.IfMatch = common.String(l.etag)
+	})
+	if err == nil {
+		l.etag = etag
+		return true
+	}
+
+	s := statusOf(err)
+	if s != http.StatusPreconditionFailed && s != http.StatusNotFound &&
+		time.Now().Before(l.expires) {
+		l.log.Warn("Unable to renew run lock",
+			slog.String("Bucket", l.bucket),
+			slog.String("error", err.Error()))
+		return true
+	}
+
+	// Taken by another instance, removed, or expired
+	l.log.Error("Run lock lost",
+		slog.String("Bucket", l.bucket),
+		slog.String("error", err.Error()))
+	l.etag = ""
+	close(l.lost)
+
+	return false
+}
+
+// put writes a lease held by this instance, returning the new ETag
+func (l *ObjectLock) put(cond func(*objectstorage.PutObjectRequest)) (string, error) {
+	expires := time.Now().Add(l.ttl)
+	b, err := json.Marshal(lease{Holder: l.holder, Expires: expires})
+	if err != nil {
+		return "", err
+	}
+
+	req := objectstorage.PutObjectRequest{
+		NamespaceName: common.String(l.namespace),
+		BucketName:    common.String(l.bucket),
+		ObjectName:    common.String(LOCK_OBJECT),
+		ContentLength: common.Int64(int64(len(b))),
+		PutObjectBody: io.NopCloser(bytes.NewReader(b)),
+	}
+	cond(&req)
+
+	resp, err := l.client.PutObject(context.Background(), req)
+	if err != nil {
+		return "", err
+	}
+	l.expires = expires
+
+	return *resp.ETag, nil
+}
+
+// statusOf returns the HTTP status of a service error, 0 for other errors
+func statusOf(err error) int {
+	if se, ok := common.IsServiceError(err); ok {
+		return se.GetHTTPStatusCode()
+	}
+
+	return 0
+}
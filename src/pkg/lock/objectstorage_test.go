@@ -0,0 +1,192 @@
+package lock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+)
+
+// serviceError is an Object Storage error with an HTTP status
+type serviceError int
+
+func (e serviceError) Error() string           { return http.StatusText(int(e)) }
+func (e serviceError) GetHTTPStatusCode() int  { return int(e) }
+func (e serviceError) GetMessage() string      { return e.Error() }
+func (e serviceError) GetCode() string         { return strconv.Itoa(int(e)) }
+func (e serviceError) GetOpcRequestID() string { return "" }
+
+// fakeObjectStorage holds a single object and honours conditional requests
+type fakeObjectStorage struct {
+	mu      sync.Mutex
+	content []byte // Nil if the object does not exist
+	etag    int
+	putErr  error // Returned by every put if set
+}
+
+func (c *fakeObjectStorage) GetObject(context.Context,
+	objectstorage.GetObjectRequest) (objectstorage.GetObjectResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.content == nil {
+		return objectstorage.GetObjectResponse{}, serviceError(http.StatusNotFound)
+	}
+
+	etag := strconv.Itoa(c.etag)
+	return objectstorage.GetObjectResponse{
+		Content: io.NopCloser(bytes.NewReader(c.content)),
+		ETag:    &etag,
+	}, nil
+}
+
+func (c *fakeObjectStorage) PutObject(_ context.Context,
+	req objectstorage.PutObjectRequest) (objectstorage.PutObjectResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case c.putErr != nil:
+		return objectstorage.PutObjectResponse{}, c.putErr
+	case req.IfNoneMatch != nil && c.content != nil,
+		req.IfMatch != nil && (c.content == nil || *req.IfMatch != strconv.Itoa(c.etag)):
+		return objectstorage.PutObjectResponse{}, serviceError(http.StatusPreconditionFailed)
+	}
+
+	c.content, _ = io.ReadAll(req.PutObjectBody)
+	c.etag++
+	etag := strconv.Itoa(c.etag)
+	return objectstorage.PutObjectResponse{ETag: &etag}, nil
+}
+
+func (c *fakeObjectStorage) DeleteObject(_ context.Context,
+	req objectstorage.DeleteObjectRequest) (objectstorage.DeleteObjectResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.content == nil {
+		return objectstorage.DeleteObjectResponse{}, serviceError(http.StatusNotFound)
+	} else if req.IfMatch != nil && *req.IfMatch != strconv.Itoa(c.etag) {
+		return objectstorage.DeleteObjectResponse{}, serviceError(http.StatusPreconditionFailed)
+	}
+
+	c.content = nil
+	return objectstorage.DeleteObjectResponse{}, nil
+}
+
+// write replaces the object with a lease of another holder
+func (c *fakeObjectStorage) write(holder string, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.content, _ = json.Marshal(lease{Holder: holder, Expires: expires})
+	c.etag++
+}
+
+func testObjectLock(client *fakeObjectStorage, holder string, ttl time.Duration) *ObjectLock {
+	return &ObjectLock{
+		client:    client,
+		namespace: "namespace",
+		bucket:    "frugal",
+		holder:    holder,
+		ttl:       ttl,
+		log:       slog.Default(),
+	}
+}
+
+func TestObjectLock(t *testing.T) {
+	client := &fakeObjectStorage{}
+	a := testObjectLock(client, "a", time.Minute)
+	b := testObjectLock(client, "b", time.Minute)
+
+	ok, err := a.TryLock()
+	if err != nil || !ok {
+		t.Fatalf("expected first instance to lock, got %v, %v", ok, err)
+	}
+
+	// Other instances idle while the lease is held
+	ok, err = b.TryLock()
+	if err != nil || ok {
+		t.Fatalf("expected second instance not to lock, got %v, %v", ok, err)
+	}
+
+	if err := a.Unlock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.content != nil {
+		t.Fatalf("expected lease to be deleted on unlock")
+	}
+
+	ok, err = b.TryLock()
+	if err != nil || !ok {
+		t.Fatalf("expected second instance to lock after release, got %v, %v", ok, err)
+	}
+	b.Unlock()
+}
+
+func TestObjectLock_ExpiredTakeover(t *testing.T) {
+	client := &fakeObjectStorage{}
+	client.write("crashed", time.Now().Add(-time.Second))
+	l := testObjectLock(client, "a", time.Minute)
+
+	ok, err := l.TryLock()
+	if err != nil || !ok {
+		t.Fatalf("expected expired lease to be taken over, got %v, %v", ok, err)
+	}
+	defer l.Unlock()
+
+	var current lease
+	if err := json.Unmarshal(client.content, &current); err != nil || current.Holder != "a" {
+		t.Fatalf("expected lease held by a, got %+v, %v", current, err)
+	}
+
+	// A lease that has not expired is left alone
+	client.write("running", time.Now().Add(time.Minute))
+	other := testObjectLock(client, "b", time.Minute)
+	if ok, err := other.TryLock(); err != nil || ok {
+		t.Fatalf("expected held lease not to be taken, got %v, %v", ok, err)
+	}
+}
+
+func TestObjectLock_Lost(t *testing.T) {
+	cases := []struct {
+		name string
+		lose func(*fakeObjectStorage)
+	}{
+		{"taken", func(c *fakeObjectStorage) {
+			c.write("b", time.Now().Add(time.Minute))
+		}},
+		{"renewal failing past expiry", func(c *fakeObjectStorage) {
+			c.mu.Lock()
+			c.putErr = serviceError(http.StatusInternalServerError)
+			c.mu.Unlock()
+		}},
+	}
+
+	for _, c := range cases {
+		client := &fakeObjectStorage{}
+		l := testObjectLock(client, "a", 30*time.Millisecond)
+		if ok, err := l.TryLock(); err != nil || !ok {
+			t.Fatalf("%s: expected to lock, got %v, %v", c.name, ok, err)
+		}
+
+		c.lose(client)
+		select {
+		case <-l.Lost():
+		case <-time.After(time.Second):
+			t.Fatalf("%s: expected lease to be reported lost", c.name)
+		}
+
+		if err := l.Unlock(); err != nil {
+			t.Errorf("%s: unexpected error unlocking lost lease: %v", c.name, err)
+		}
+	}
+}
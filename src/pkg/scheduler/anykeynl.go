@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"fmt"
+	"maps"
 	"strconv"
 	"strings"
 	"time"
@@ -29,16 +30,22 @@ const (
 	_WEEKDAY string = "WeekDay"
 	_WEEKEND string = "Weekend"
 	_DAYOFMO string = "DayOfMonth"
+	_GROUP   string = "Group"
 )
 
 // AnykeyNL Scheduler inspired by https://github.com/AnykeyNL/OCI-AutoScale and
-// aims to have similar ruleset. Intended to run once an hour.
+// aims to have similar ruleset. Intended to run once an hour, or more often with
+// time range schedules evaluated through Transition.
 type AnykeyNLScheduler struct {
-	loc  *time.Location
-	hour int
-	dow  string // day of week
-	dom  int    // day of month
-	dnr  int    // nth day within the month (1..5)
+	loc    *time.Location
+	hour   int
+	minute int
+	dow    string // day of week
+	dom    int    // day of month
+	dnr    int    // nth day within the month (1..5)
+
+	groups    map[string]map[string]string // schedule keys by group name
+	templates map[string]string            // schedules by template name
 }
 
 // NewAnykeyNLScheduler creates a scheduler using the local system timezone.
@@ -53,16 +60,28 @@ func NewAnykeyNLSchedulerWithLocation(loc *time.Location) *AnykeyNLScheduler {
 		loc = time.Local
 	}
 
+	ts := AnykeyNLScheduler{
+		loc:       loc,
+		groups:    make(map[string]map[string]string),
+		templates: maps.Clone(builtinTemplates),
+	}
+
 	// Determine current time components based on configured scheduler timezone
-	now := time.Now().In(loc)
+	ts = ts.at(time.Now())
+	return &ts
+}
 
-	return &AnykeyNLScheduler{
-		loc:  loc,
-		hour: now.Hour(),
-		dow:  now.Weekday().String(),
-		dom:  now.Day(),
-		dnr:  nthInMonth(now),
-	}
+// at returns a copy of the scheduler evaluating schedules at t
+func (ts AnykeyNLScheduler) at(t time.Time) AnykeyNLScheduler {
+	t = t.In(ts.loc)
+
+	ts.hour = t.Hour()
+	ts.minute = t.Minute()
+	ts.dow = t.Weekday().String()
+	ts.dom = t.Day()
+	ts.dnr = nthInMonth(t)
+
+	return ts
 }
 
 // Evaluate determines an action to take on the resource.
@@ -73,11 +92,11 @@ func (ts AnykeyNLScheduler) Evaluate(input any) (action.Action, error) {
 	switch v := input.(type) {
 	case string:
 		// Direct evaluation of schedule string
-		return ts.parseSchedule(v, ts.hour)
+		return ts.evaluate(v)
 	case []byte:
-		return ts.parseSchedule(string(v), ts.hour)
+		return ts.evaluate(string(v))
 	case fmt.Stringer:
-		return ts.parseSchedule(v.String(), ts.hour)
+		return ts.evaluate(v.String())
 	default:
 		// Treat as tags and resolve today's active schedule
 		active, err := ts.ActiveSchedule(input)
@@ -90,13 +109,78 @@ func (ts AnykeyNLScheduler) Evaluate(input any) (action.Action, error) {
 			return action.NULL_ACTION, nil
 		}
 
-		return ts.parseSchedule(active, ts.hour)
+		return ts.evaluate(active)
 	}
 }
 
+// Transition evaluates input minute by minute after since up to until and
+// returns the action of the latest change, ignoring changes to a null action. Each
+// change falls in exactly one of consecutive periods, so runs passing the previous
+// run's until as since take each action once.
+func (ts AnykeyNLScheduler) Transition(input any, since, until time.Time) (action.Action,
+	error) {
+	act := action.NULL_ACTION
+
+	t := since.Truncate(time.Minute)
+	prev, err := ts.at(t).Evaluate(input)
+	if err != nil {
+		return act, err
+	}
+
+	for t = t.Add(time.Minute); !t.After(until); t = t.Add(time.Minute) {
+		a, err := ts.at(t).Evaluate(input)
+		if err != nil {
+			return action.NULL_ACTION, err
+		}
+
+		if a != prev && a != action.NULL_ACTION {
+			act = a
+		}
+		prev = a
+	}
+
+	return act, nil
+}
+
+// evaluate resolves a template reference and parses the schedule for the
+// current hour
+func (ts AnykeyNLScheduler) evaluate(sch string) (action.Action, error) {
+	sch, err := ts.resolveTemplate(sch)
+	if err != nil {
+		return action.NULL_ACTION, err
+	}
+
+	return ts.parseSchedule(sch, ts.hour*60+ts.minute)
+}
+
+// resolveTemplate returns the schedule of a template reference such as
+// @office-hours, or sch unchanged if it is not a reference
+func (ts AnykeyNLScheduler) resolveTemplate(sch string) (string, error) {
+	name, ok := templateName(sch)
+	if !ok {
+		return sch, nil
+	}
+
+	t, ok := ts.templates[name]
+	if !ok {
+		return "", ErrUnknownTemplate{Name: name}
+	}
+
+	return t, nil
+}
+
+// SetTemplate defines a named schedule, replacing any existing. A leading @ in
+// name is ignored.
+func (ts *AnykeyNLScheduler) SetTemplate(name, schedule string) {
+	ts.templates[strings.TrimPrefix(name, TEMPLATE_PREFIX)] = schedule
+}
+
 // ActiveSchedule determines the active schedule per AnykeyNL priority
 // (least -> most specific), with later matches overriding earlier ones:
 // AnyDay -> WeekDay/Weekend -> Day-of-week -> Nth day-of-week in month -> DayOfMonth
+//
+// Resources with a Group key also use the keys of the group's schedule. Keys
+// set on the resource win over the same keys in the group.
 func (ts AnykeyNLScheduler) ActiveSchedule(tags any) (string, error) {
 	// Normalize tags into map[string]string
 	t, err := toStringMap(tags)
@@ -104,6 +188,11 @@ func (ts AnykeyNLScheduler) ActiveSchedule(tags any) (string, error) {
 		return "", err
 	}
 
+	t, err = ts.withGroup(t)
+	if err != nil {
+		return "", err
+	}
+
 	active := ""
 
 	if v, ok := t[_ANYDAY]; ok && strings.TrimSpace(v) != "" {
@@ -134,13 +223,61 @@ func (ts AnykeyNLScheduler) ActiveSchedule(tags any) (string, error) {
 	return active, nil
 }
 
+// SetGroup defines the schedule keys of a group, replacing any existing
+func (ts *AnykeyNLScheduler) SetGroup(name string, keys map[string]string) {
+	group := make(map[string]string, len(keys))
+	for k, v := range keys {
+		if k != _GROUP {
+			group[k] = v
+		}
+	}
+
+	ts.groups[name] = group
+}
+
+// withGroup merges the schedule keys of the resource's group under the
+// resource's own keys. A group without a defined schedule may be supplied by a
+// key named after the group, such as one populated by a tag default, which is
+// used as the group's AnyDay schedule.
+func (ts AnykeyNLScheduler) withGroup(t map[string]string) (map[string]string, error) {
+	name := strings.TrimSpace(t[_GROUP])
+	if name == "" {
+		return t, nil
+	}
+
+	group, ok := ts.groups[name]
+	if !ok {
+		v, ok := t[name]
+		if !ok || strings.TrimSpace(v) == "" {
+			return t, ErrUnknownGroup{Name: name}
+		}
+		group = map[string]string{_ANYDAY: v}
+	}
+
+	merged := make(map[string]string, len(group)+len(t))
+	for k, v := range group {
+		merged[k] = v
+	}
+	for k, v := range t {
+		if strings.TrimSpace(v) != "" {
+			merged[k] = v
+		}
+	}
+
+	return merged, nil
+}
+
 // SetLocation changes the timezone of the scheduler
 func (ts *AnykeyNLScheduler) SetLocation(loc *time.Location) (Scheduler, error) {
 	if loc == nil {
 		return ts, ErrInvalidTimezone
 	}
 
-	return NewAnykeyNLSchedulerWithLocation(loc), nil
+	n := NewAnykeyNLSchedulerWithLocation(loc)
+	n.groups = ts.groups
+	n.templates = ts.templates
+
+	return n, nil
 }
 
 // Type returns the scheduler type
@@ -148,7 +285,9 @@ func (ts *AnykeyNLScheduler) Type() string {
 	return configuration.ANYKEYNL_SCHEDULER
 }
 
-func (ts AnykeyNLScheduler) parseSchedule(sch string, hour int) (action.Action,
+// parseSchedule returns the action of a schedule at minute of the day. Hourly
+// schedules use the token of the minute's hour.
+func (ts AnykeyNLScheduler) parseSchedule(sch string, minute int) (action.Action,
 	error) {
 	// Default: null action
 	act := action.NULL_ACTION
@@ -164,10 +303,20 @@ func (ts AnykeyNLScheduler) parseSchedule(sch string, hour int) (action.Action,
 		return act, nil
 	}
 
-	tokens := strings.Split(sch, ",")
-	// Trim tokens
-	for i := range tokens {
-		tokens[i] = strings.TrimSpace(tokens[i])
+	tokens := splitTokens(sch)
+
+	// Time ranges such as 08:00-18:00 or 7-12,13-19 are on within a window and
+	// off outside of all windows
+	if isRange(tokens[0]) {
+		windows, err := parseRanges(tokens)
+		if err != nil {
+			return act, err
+		}
+
+		if inWindow(windows, minute) {
+			return action.ON, nil
+		}
+		return action.OFF, nil
 	}
 
 	// Enforce exactly 24 tokens
@@ -175,7 +324,7 @@ func (ts AnykeyNLScheduler) parseSchedule(sch string, hour int) (action.Action,
 		return act, ErrInvalidTokenCount{Expected: 24, Got: len(tokens)}
 	}
 
-	want := tokens[hour]
+	want := tokens[minute/60]
 	if want == "" || want == "*" {
 		return act, nil
 	}
@@ -152,3 +152,184 @@ func TestEvaluate_DirectScheduleString_InvalidCount(t *testing.T) {
 		t.Fatalf("expected NULL_ACTION on error, got %v", act)
 	}
 }
+
+func TestActiveSchedule_Group(t *testing.T) {
+	sch := NewAnykeyNLSchedulerWithLocation(time.UTC)
+	sch.SetGroup("payments-dev", map[string]string{"AnyDay": repeat24("0")})
+
+	// Group schedule applies to members
+	active, err := sch.ActiveSchedule(map[string]string{"Group": "payments-dev"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if active != repeat24("0") {
+		t.Fatalf("expected group schedule, got %q", active)
+	}
+
+	// Resource keys win over the group's
+	active, err = sch.ActiveSchedule(map[string]string{
+		"Group":  "payments-dev",
+		"AnyDay": repeat24("1"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if active != repeat24("1") {
+		t.Fatalf("expected resource schedule, got %q", active)
+	}
+
+	// Key named after the group supplies its schedule
+	active, err = sch.ActiveSchedule(map[string]string{
+		"Group":   "batch",
+		"batch":   repeat24("*"),
+		"Unknown": "x",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if active != repeat24("*") {
+		t.Fatalf("expected tag default schedule, got %q", active)
+	}
+
+	// Undefined groups are an error
+	if _, err := sch.ActiveSchedule(map[string]string{"Group": "missing"}); err == nil {
+		t.Fatalf("expected error for undefined group")
+	} else if _, ok := err.(ErrUnknownGroup); !ok {
+		t.Fatalf("expected ErrUnknownGroup, got %T: %v", err, err)
+	}
+}
+
+func TestEvaluate_Template(t *testing.T) {
+	sch := NewAnykeyNLSchedulerWithLocation(time.UTC)
+	sch.hour = 9
+	sch.SetTemplate("@late-start", repeat24("0"))
+
+	// Built-in template
+	act, err := sch.Evaluate(map[string]string{"AnyDay": "@office-hours"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if act != action.ON {
+		t.Fatalf("expected ON from built-in template, got %v", act)
+	}
+
+	// Precedence is resolved before the template is expanded
+	act, err = sch.Evaluate(map[string]string{
+		"AnyDay": "@office-hours",
+		sch.dow:  "@late-start # not before noon",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if act != action.OFF {
+		t.Fatalf("expected OFF from defined template, got %v", act)
+	}
+
+	// Undefined templates are an error
+	if _, err := sch.Evaluate("@missing"); err == nil {
+		t.Fatalf("expected error for undefined template")
+	} else if _, ok := err.(ErrUnknownTemplate); !ok {
+		t.Fatalf("expected ErrUnknownTemplate, got %T: %v", err, err)
+	}
+}
+
+func TestEvaluate_Ranges(t *testing.T) {
+	sch := NewAnykeyNLSchedulerWithLocation(time.UTC)
+
+	tests := []struct {
+		schedule string
+		hour     int
+		want     action.Action
+	}{
+		{"08:00-18:00", 8, action.ON},
+		{"08:00-18:00", 17, action.ON},
+		{"08:00-18:00", 18, action.OFF},
+		{"8-18", 7, action.OFF},
+		{"7-12,13-19", 12, action.OFF},
+		{"7-12, 13-19", 13, action.ON},
+		{"22-6", 23, action.ON},
+		{"22-6", 3, action.ON},
+		{"22-6", 6, action.OFF},
+		{"18-24", 23, action.ON},
+		{"0-24", 0, action.ON},
+		{"0-24", 23, action.ON},
+		{"00:00-24:00", 12, action.ON},
+	}
+
+	for _, tt := range tests {
+		sch.hour = tt.hour
+		act, err := sch.Evaluate(tt.schedule)
+		if err != nil {
+			t.Fatalf("%q at %d: unexpected error: %v", tt.schedule, tt.hour, err)
+		}
+		if act != tt.want {
+			t.Fatalf("%q at %d: expected %v, got %v", tt.schedule, tt.hour, tt.want, act)
+		}
+	}
+
+	for _, bad := range []string{"8-18,1", "8-25", "8-8", "0-0", "24-0", "08:60-18:00", "24:30-1"} {
+		if _, err := sch.Evaluate(bad); err == nil {
+			t.Fatalf("%q: expected error", bad)
+		} else if _, ok := err.(ErrInvalidToken); !ok {
+			t.Fatalf("%q: expected ErrInvalidToken, got %T: %v", bad, err, err)
+		}
+	}
+}
+
+func TestEvaluate_MinuteRanges(t *testing.T) {
+	day := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	sch := NewAnykeyNLSchedulerWithLocation(time.UTC)
+
+	tests := []struct {
+		at   time.Duration
+		want action.Action
+	}{
+		{7*time.Hour + 44*time.Minute, action.OFF},
+		{7*time.Hour + 45*time.Minute, action.ON},
+		{18*time.Hour + 29*time.Minute, action.ON},
+		{18*time.Hour + 30*time.Minute, action.OFF},
+	}
+
+	for _, tt := range tests {
+		act, err := sch.at(day.Add(tt.at)).Evaluate("07:45-18:30")
+		if err != nil {
+			t.Fatalf("at %v: unexpected error: %v", tt.at, err)
+		}
+		if act != tt.want {
+			t.Fatalf("at %v: expected %v, got %v", tt.at, tt.want, act)
+		}
+	}
+}
+
+func TestTransition_FifteenMinuteRuns(t *testing.T) {
+	day := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	sch := NewAnykeyNLSchedulerWithLocation(time.UTC)
+	tags := map[string]string{"AnyDay": "07:45-18:30"}
+
+	// Runs start a few seconds late, each evaluating since the previous run
+	var fired []action.Action
+	last := day.Add(-15 * time.Minute)
+	for run := day.Add(3 * time.Second); run.Before(day.Add(24 * time.Hour)); run = run.Add(15 * time.Minute) {
+		act, err := sch.Transition(tags, last, run)
+		if err != nil {
+			t.Fatalf("at %v: unexpected error: %v", run, err)
+		}
+		if act != action.NULL_ACTION {
+			fired = append(fired, act)
+		}
+		last = run
+	}
+
+	if len(fired) != 2 || fired[0] != action.ON || fired[1] != action.OFF {
+		t.Fatalf("expected one ON and one OFF, got %v", fired)
+	}
+
+	// A missed run is replayed, the latest transition wins
+	act, err := sch.Transition(tags, day.Add(7*time.Hour), day.Add(19*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if act != action.OFF {
+		t.Fatalf("expected OFF after missed runs, got %v", act)
+	}
+}
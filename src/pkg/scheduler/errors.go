@@ -52,3 +52,21 @@ type ErrUnsupportedToken struct {
 func (e ErrUnsupportedToken) Error() string {
 	return fmt.Sprintf("unsupported schedule token: %q", e.Token)
 }
+
+// ErrUnknownGroup indicates a resource references a group with no schedule
+type ErrUnknownGroup struct {
+	Name string
+}
+
+func (e ErrUnknownGroup) Error() string {
+	return fmt.Sprintf("no schedule defined for group %q", e.Name)
+}
+
+// ErrUnknownTemplate indicates a schedule references an undefined template
+type ErrUnknownTemplate struct {
+	Name string
+}
+
+func (e ErrUnknownTemplate) Error() string {
+	return fmt.Sprintf("no schedule template named %q", e.Name)
+}
@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const _DAYMINUTES int = 24 * 60
+
+// Matches time ranges such as 8-18 or 08:00-18:00
+var rangePattern = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*-\s*(\d{1,2})(?::(\d{2}))?$`)
+
+// window is the part of a day [start, end) a range schedule is on, in minutes
+// after midnight. Windows with start after end wrap past midnight.
+type window struct {
+	start int
+	end   int
+}
+
+func (w window) contains(minute int) bool {
+	if w.start < w.end {
+		return minute >= w.start && minute < w.end
+	}
+
+	return minute >= w.start || minute < w.end
+}
+
+// isRange returns true if a schedule token is a time range
+func isRange(token string) bool {
+	return rangePattern.MatchString(token)
+}
+
+// parseRanges parses range schedule tokens such as 7-12,13-19 into windows.
+// Every token must be a range.
+func parseRanges(tokens []string) ([]window, error) {
+	windows := make([]window, 0, len(tokens))
+	for _, token := range tokens {
+		m := rangePattern.FindStringSubmatch(token)
+		if m == nil {
+			return nil, ErrInvalidToken{Token: token,
+				Reason: "expected a time range such as 08:00-18:00"}
+		}
+
+		start, err := parseClock(token, m[1], m[2])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(token, m[3], m[4])
+		if err != nil {
+			return nil, err
+		}
+
+		// Midnight to midnight is the whole day [ex. 0-24]
+		if start == 0 && end == _DAYMINUTES {
+			windows = append(windows, window{start: 0, end: _DAYMINUTES})
+			continue
+		}
+
+		if start%_DAYMINUTES == end%_DAYMINUTES {
+			return nil, ErrInvalidToken{Token: token, Reason: "range is empty"}
+		}
+
+		windows = append(windows, window{start: start % _DAYMINUTES, end: end % _DAYMINUTES})
+	}
+
+	return windows, nil
+}
+
+// parseClock returns the minutes after midnight of an hour and optional minute
+func parseClock(token, hour, minute string) (int, error) {
+	h, err := strconv.Atoi(hour)
+	if err != nil {
+		return 0, ErrInvalidToken{Token: token, Reason: err.Error()}
+	}
+
+	m := 0
+	if minute != "" {
+		if m, err = strconv.Atoi(minute); err != nil {
+			return 0, ErrInvalidToken{Token: token, Reason: err.Error()}
+		}
+	}
+
+	switch {
+	case h > 24 || (h == 24 && m != 0):
+		return 0, ErrInvalidToken{Token: token, Reason: "hour must be between 0 and 24"}
+	case m > 59:
+		return 0, ErrInvalidToken{Token: token, Reason: "minute must be between 0 and 59"}
+	}
+
+	return h*60 + m, nil
+}
+
+// inWindow returns true if a minute of the day falls within any window
+func inWindow(windows []window, minute int) bool {
+	for _, w := range windows {
+		if w.contains(minute) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitTokens splits a schedule into trimmed comma separated tokens
+func splitTokens(sch string) []string {
+	tokens := strings.Split(sch, ",")
+	for i := range tokens {
+		tokens[i] = strings.TrimSpace(tokens[i])
+	}
+
+	return tokens
+}
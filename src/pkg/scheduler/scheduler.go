@@ -19,6 +19,21 @@ type Scheduler interface {
 	ActiveSchedule(any) (string, error)
 }
 
+// Grouper is implemented by schedulers that resolve schedules of named resource
+// groups
+type Grouper interface {
+	// SetGroup defines the schedule keys of a group, replacing any existing
+	SetGroup(name string, keys map[string]string)
+}
+
+// Transitioner is implemented by schedulers that evaluate schedule changes over a
+// period rather than at a single point in time
+type Transitioner interface {
+	// Transition returns the action of the latest schedule change after since up
+	// to until, or a null action if the schedule did not change
+	Transition(input any, since, until time.Time) (action.Action, error)
+}
+
 type NullScheduler struct{}
 
 func (n *NullScheduler) Evaluate(any) (action.Action, error) {
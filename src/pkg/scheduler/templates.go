@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"strings"
+)
+
+// Prefix marking a schedule value as a template reference [ex. @office-hours]
+const TEMPLATE_PREFIX string = "@"
+
+// Built-in schedule templates available without a schedule file
+var builtinTemplates map[string]string = map[string]string{
+	// Always on
+	"always-on": "1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1",
+	// Always off
+	"always-off": "0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0",
+	// On 08:00-18:00
+	"office-hours": "0,0,0,0,0,0,0,0,1,1,1,1,1,1,1,1,1,1,0,0,0,0,0,0",
+	// On 06:00-22:00
+	"extended-hours": "0,0,0,0,0,0,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,0,0",
+}
+
+// Templater is implemented by schedulers that resolve named schedule templates
+type Templater interface {
+	// SetTemplate defines a named schedule, replacing any existing
+	SetTemplate(name, schedule string)
+}
+
+// templateName returns the template referenced by a schedule value and true if
+// the value is a template reference. Inline comments are ignored.
+func templateName(sch string) (string, bool) {
+	if idx := strings.Index(sch, "#"); idx >= 0 {
+		sch = sch[:idx]
+	}
+
+	sch = strings.TrimSpace(sch)
+	if !strings.HasPrefix(sch, TEMPLATE_PREFIX) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(sch, TEMPLATE_PREFIX), true
+}
@@ -0,0 +1,85 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStore keeps last runs in a local JSON file
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// LastRun returns the last run for key
+func (s *FileStore) LastRun(key string) (Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.read()
+	if err != nil {
+		return Run{}, err
+	}
+
+	return runs[key], nil
+}
+
+// SetLastRun records the run for key
+func (s *FileStore) SetLastRun(key string, r Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.read()
+	if err != nil {
+		return err
+	}
+	runs[key] = r
+
+	b, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Replace the file whole so a crash cannot leave it partially written
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// read returns the last runs in the file, empty if it does not exist
+func (s *FileStore) read() (map[string]Run, error) {
+	runs := make(map[string]Run)
+
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return runs, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &runs); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,42 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s := NewFileStore(path)
+
+	// Missing file has no last run
+	last, err := s.LastRun("us-ashburn-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !last.Time.IsZero() {
+		t.Fatalf("expected zero time, got %v", last.Time)
+	}
+
+	run := time.Date(2024, 5, 6, 19, 0, 0, 0, time.UTC)
+	retry := map[string]time.Time{"ocid1.instance.oc1..a": run.Add(-time.Hour)}
+	if err := s.SetLastRun("us-ashburn-1", Run{Time: run, Retry: retry}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.SetLastRun("eu-frankfurt-1", Run{Time: run.Add(time.Hour)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Runs persist per key across stores
+	last, err = NewFileStore(path).LastRun("us-ashburn-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !last.Time.Equal(run) {
+		t.Fatalf("expected %v, got %v", run, last.Time)
+	}
+	if !last.Retry["ocid1.instance.oc1..a"].Equal(run.Add(-time.Hour)) {
+		t.Fatalf("expected retry to persist, got %v", last.Retry)
+	}
+}
@@ -0,0 +1,86 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+)
+
+const (
+	// Prefix of objects holding last runs
+	OBJECT_PREFIX string = "frugal/last-run/"
+)
+
+// objectStorageClient is the part of objectstorage.ObjectStorageClient used to
+// keep last runs
+type objectStorageClient interface {
+	GetObject(context.Context, objectstorage.GetObjectRequest) (objectstorage.GetObjectResponse, error)
+	PutObject(context.Context, objectstorage.PutObjectRequest) (objectstorage.PutObjectResponse, error)
+}
+
+// ObjectStore keeps last runs as objects in an Object Storage bucket so
+// runs on different hosts share state
+type ObjectStore struct {
+	client    objectStorageClient
+	namespace string
+	bucket    string
+}
+
+func NewObjectStore(cfg common.ConfigurationProvider, bucket string) (*ObjectStore, error) {
+	client, err := objectstorage.NewObjectStorageClientWithConfigurationProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.GetNamespace(context.Background(),
+		objectstorage.GetNamespaceRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ObjectStore{client: &client, namespace: *resp.Value, bucket: bucket}, nil
+}
+
+// LastRun returns the last run for key
+func (s *ObjectStore) LastRun(key string) (Run, error) {
+	resp, err := s.client.GetObject(context.Background(), objectstorage.GetObjectRequest{
+		NamespaceName: common.String(s.namespace),
+		BucketName:    common.String(s.bucket),
+		ObjectName:    common.String(OBJECT_PREFIX + key),
+	})
+	if err != nil {
+		if se, ok := common.IsServiceError(err); ok &&
+			se.GetHTTPStatusCode() == http.StatusNotFound {
+			return Run{}, nil
+		}
+		return Run{}, err
+	}
+	defer resp.Content.Close()
+
+	var r Run
+	err = json.NewDecoder(resp.Content).Decode(&r)
+	return r, err
+}
+
+// SetLastRun records the run for key
+func (s *ObjectStore) SetLastRun(key string, r Run) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.PutObject(context.Background(), objectstorage.PutObjectRequest{
+		NamespaceName: common.String(s.namespace),
+		BucketName:    common.String(s.bucket),
+		ObjectName:    common.String(OBJECT_PREFIX + key),
+		ContentLength: common.Int64(int64(len(b))),
+		PutObjectBody: io.NopCloser(bytes.NewReader(b)),
+	})
+
+	return err
+}
@@ -0,0 +1,94 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+)
+
+// serviceError is an Object Storage error with an HTTP status
+type serviceError int
+
+func (e serviceError) Error() string           { return http.StatusText(int(e)) }
+func (e serviceError) GetHTTPStatusCode() int  { return int(e) }
+func (e serviceError) GetMessage() string      { return e.Error() }
+func (e serviceError) GetCode() string         { return strconv.Itoa(int(e)) }
+func (e serviceError) GetOpcRequestID() string { return "" }
+
+// fakeObjectStorage keeps objects by name
+type fakeObjectStorage struct {
+	objects map[string][]byte
+	getErr  error // Returned by every get if set
+}
+
+func (c *fakeObjectStorage) GetObject(_ context.Context,
+	req objectstorage.GetObjectRequest) (objectstorage.GetObjectResponse, error) {
+	if c.getErr != nil {
+		return objectstorage.GetObjectResponse{}, c.getErr
+	}
+
+	b, ok := c.objects[*req.ObjectName]
+	if !ok {
+		return objectstorage.GetObjectResponse{}, serviceError(http.StatusNotFound)
+	}
+
+	return objectstorage.GetObjectResponse{Content: io.NopCloser(bytes.NewReader(b))}, nil
+}
+
+func (c *fakeObjectStorage) PutObject(_ context.Context,
+	req objectstorage.PutObjectRequest) (objectstorage.PutObjectResponse, error) {
+	b, err := io.ReadAll(req.PutObjectBody)
+	if err != nil {
+		return objectstorage.PutObjectResponse{}, err
+	}
+	c.objects[*req.ObjectName] = b
+
+	return objectstorage.PutObjectResponse{}, nil
+}
+
+func TestObjectStore(t *testing.T) {
+	client := &fakeObjectStorage{objects: make(map[string][]byte)}
+	s := &ObjectStore{client: client, namespace: "namespace", bucket: "frugal"}
+
+	// Missing object has no last run
+	last, err := s.LastRun("us-ashburn-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !last.Time.IsZero() {
+		t.Fatalf("expected zero time, got %v", last.Time)
+	}
+
+	run := time.Date(2024, 5, 6, 19, 0, 0, 0, time.UTC)
+	retry := map[string]time.Time{"ocid1.instance.oc1..a": run.Add(-time.Hour)}
+	if err := s.SetLastRun("us-ashburn-1", Run{Time: run, Retry: retry}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := client.objects[OBJECT_PREFIX+"us-ashburn-1"]; !ok {
+		t.Fatalf("expected run under %s, got %v", OBJECT_PREFIX, client.objects)
+	}
+
+	last, err = s.LastRun("us-ashburn-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !last.Time.Equal(run) {
+		t.Fatalf("expected %v, got %v", run, last.Time)
+	}
+	if !last.Retry["ocid1.instance.oc1..a"].Equal(run.Add(-time.Hour)) {
+		t.Fatalf("expected retry to persist, got %v", last.Retry)
+	}
+
+	// Errors other than a missing object are returned
+	client.getErr = serviceError(http.StatusInternalServerError)
+	if _, err := s.LastRun("us-ashburn-1"); !errors.Is(err, client.getErr) {
+		t.Fatalf("expected service error, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package state
+
+import (
+	"time"
+)
+
+const (
+	// Longest period replayed after missed runs
+	MAX_REPLAY time.Duration = 24 * time.Hour
+)
+
+// Run is the state left by the last run for a key [ex. region]
+type Run struct {
+	Time  time.Time            `json:"time"`            // Time the last run evaluated up to
+	Retry map[string]time.Time `json:"retry,omitempty"` // Unfinished resources by OCID, replayed from an earlier time
+}
+
+// Store persists the last run per key so runs can replay schedule transitions
+// missed since
+type Store interface {
+	// LastRun returns the last run or a zero Run if there is none
+	LastRun(key string) (Run, error)
+	// SetLastRun records a run
+	SetLastRun(key string, r Run) error
+}
+
+// Next returns the state after a run that evaluated transitions after since up
+// to now. Resources the run did not finish are replayed from the time they were
+// first unfinished, every other resource from now.
+func Next(prev Run, since, now time.Time, unfinished []string) Run {
+	next := Run{Time: now, Retry: make(map[string]time.Time, len(unfinished))}
+	for _, id := range unfinished {
+		if t, ok := prev.Retry[id]; ok {
+			next.Retry[id] = t
+		} else {
+			next.Retry[id] = since
+		}
+	}
+
+	return next
+}
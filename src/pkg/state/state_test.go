@@ -0,0 +1,38 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext_PartialFailure(t *testing.T) {
+	day := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	prev := Run{
+		Time:  day.Add(18 * time.Hour),
+		Retry: map[string]time.Time{"still-failing": day.Add(17 * time.Hour), "recovered": day.Add(17 * time.Hour)},
+	}
+	since, now := prev.Time, day.Add(19*time.Hour)
+
+	next := Next(prev, since, now, []string{"still-failing", "new-failure"})
+
+	// The region advances despite failures
+	if !next.Time.Equal(now) {
+		t.Fatalf("expected last run %v, got %v", now, next.Time)
+	}
+
+	cases := []struct {
+		id   string
+		want time.Time
+		ok   bool
+	}{
+		{"still-failing", day.Add(17 * time.Hour), true}, // Keeps its first unfinished time
+		{"new-failure", since, true},                     // Replayed from this run
+		{"recovered", time.Time{}, false},                // Finished, no longer replayed
+	}
+	for _, c := range cases {
+		got, ok := next.Retry[c.id]
+		if ok != c.ok || !got.Equal(c.want) {
+			t.Errorf("%s: got %v, %v, want %v, %v", c.id, got, ok, c.want, c.ok)
+		}
+	}
+}
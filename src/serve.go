@@ -0,0 +1,369 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/configuration"
+	"github.com/flynnkc/oci-frugal/src/pkg/controller"
+	"github.com/flynnkc/oci-frugal/src/pkg/lock"
+)
+
+const (
+	// Time allowed for requests to finish on shutdown
+	SHUTDOWN_TIMEOUT time.Duration = 30 * time.Second
+)
+
+var (
+	ErrRunInProgress error = errors.New("run in progress or run lock held by another instance")
+	ErrUnknownRegion error = errors.New("region not subscribed")
+	ErrUnauthorized  error = errors.New("missing or invalid bearer token")
+)
+
+// runResult is the outcome of a completed run
+type runResult struct {
+	Region      string                   `json:"region,omitempty"`
+	Compartment string                   `json:"compartment,omitempty"`
+	Start       time.Time                `json:"start"`
+	End         time.Time                `json:"end"`
+	Regions     []*controller.RunSummary `json:"regions"`
+}
+
+// runStatus reports whether a run is in progress and the last completed run
+type runStatus struct {
+	Running bool       `json:"running"`
+	Last    *runResult `json:"last,omitempty"`
+}
+
+// runScope limits a run or plan to regions and a compartment and beneath it
+type runScope struct {
+	region      string // Requested region, empty for all
+	regions     []string
+	compartment string // Compartment OCID, empty for all
+	defaults    *controller.CompartmentDefaults
+}
+
+// server runs Frugal as a daemon, running every interval if set and on demand
+// over HTTP. Runs share the run lock with other instances. Every route except
+// the health check requires the API token as a bearer token.
+type server struct {
+	token   string
+	lock    lock.Lock  // Nil if instances do not coordinate
+	running sync.Mutex // Held for the duration of a run
+	runs    sync.WaitGroup
+	mu      sync.Mutex
+	status  runStatus
+	log     *slog.Logger
+
+	// Regions, compartments, runs, and plans of the configuration
+	regions  func() ([]string, error)
+	defaults func() *controller.CompartmentDefaults
+	execute  func(context.Context, runScope) []*controller.RunSummary
+	plan     func(runScope) []*controller.Plan
+}
+
+// newServer creates a server acting on the configuration
+func newServer(cfg *configuration.Configuration, l lock.Lock, log *slog.Logger) *server {
+	return &server{
+		token: cfg.APIToken(),
+		lock:  l,
+		log:   log,
+		regions: func() ([]string, error) {
+			if *cfg.Region() != "" {
+				return []string{*cfg.Region()}, nil
+			}
+			return subscribedRegions(cfg)
+		},
+		defaults: func() *controller.CompartmentDefaults {
+			return compartmentDefaults(cfg, log)
+		},
+		execute: func(ctx context.Context, sc runScope) []*controller.RunSummary {
+			return execute(ctx, cfg, sc.regions, sc.defaults, sc.compartment, log)
+		},
+		plan: func(sc runScope) []*controller.Plan {
+			return planRegions(cfg, sc.regions, sc.defaults, sc.compartment, log)
+		},
+	}
+}
+
+// handler returns the routes of the HTTP API
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /run", s.authorize(s.handleRun))
+	mux.HandleFunc("GET /plan", s.authorize(s.handlePlan))
+	mux.HandleFunc("GET /status", s.authorize(s.handleStatus))
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+
+	return mux
+}
+
+// serve runs the HTTP API until interrupted or terminated
+func serve(cfg *configuration.Configuration) {
+	log := cfg.MakeLog("Component", "Server")
+
+	if cfg.APIToken() == "" {
+		log.Error("serve requires an API token [ex. FRUGAL_API_TOKEN]")
+		os.Exit(1)
+	}
+
+	l, err := newLock(cfg)
+	if err != nil {
+		log.Error("Unable to create run lock", "error", err)
+		os.Exit(1)
+	}
+	s := newServer(cfg, l, log)
+
+	srv := &http.Server{
+		Addr:              cfg.Listen(),
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if cfg.Interval() > 0 {
+		go s.schedule(ctx, cfg.Interval())
+	}
+
+	go func() {
+		<-ctx.Done()
+		log.Info("Shutting down")
+
+		shutdown, cancel := context.WithTimeout(context.Background(), SHUTDOWN_TIMEOUT)
+		defer cancel()
+		if err := srv.Shutdown(shutdown); err != nil {
+			log.Error("Unable to shut down server", "error", err)
+		}
+	}()
+
+	log.Info("Listening", "Address", cfg.Listen(), "Interval", cfg.Interval())
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Error("Server stopped", "error", err)
+		os.Exit(1)
+	}
+
+	// Runs in progress finish and release the run lock before exiting
+	s.runs.Wait()
+}
+
+// schedule starts a run every interval until ctx is done. Instances that do not
+// hold the run lock idle until the next interval.
+func (s *server) schedule(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		sc, err := s.scope("", "")
+		if err == nil {
+			err = s.start(sc)
+		}
+		if errors.Is(err, ErrRunInProgress) {
+			s.log.Info("Skipping scheduled run", "Reason", err.Error())
+		} else if err != nil {
+			s.log.Error("Unable to start scheduled run", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// start begins a run in the background. Returns ErrRunInProgress if this or
+// another instance is running. The run stops handling tasks if the run lock is
+// lost.
+func (s *server) start(sc runScope) error {
+	if !s.running.TryLock() {
+		return ErrRunInProgress
+	}
+
+	ctx, stop := context.Background(), func() {}
+	if s.lock != nil {
+		ok, err := s.lock.TryLock()
+		if err != nil || !ok {
+			s.running.Unlock()
+			if err == nil {
+				err = ErrRunInProgress
+			}
+			return err
+		}
+		ctx, stop = lockContext(s.lock, s.log)
+	}
+
+	s.mu.Lock()
+	s.status.Running = true
+	s.mu.Unlock()
+
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		defer s.running.Unlock()
+		defer s.release()
+		defer stop()
+
+		s.run(ctx, sc)
+	}()
+
+	return nil
+}
+
+// run executes a run and records its result
+func (s *server) run(ctx context.Context, sc runScope) {
+	result := runResult{Region: sc.region, Compartment: sc.compartment, Start: time.Now()}
+	result.Regions = s.execute(ctx, sc)
+	result.End = time.Now()
+
+	s.mu.Lock()
+	s.status = runStatus{Last: &result}
+	s.mu.Unlock()
+}
+
+// release releases the run lock if held
+func (s *server) release() {
+	if s.lock == nil {
+		return
+	}
+
+	if err := s.lock.Unlock(); err != nil {
+		s.log.Error("Unable to release run lock", "error", err)
+	}
+}
+
+// scope resolves a requested region and compartment name or OCID, either empty
+// for all. Regions must be subscribed. Unknown regions and compartments return
+// ErrUnknownRegion, controller.ErrUnknownCompartment, and
+// controller.ErrAmbiguousCompartment.
+func (s *server) scope(region, compartment string) (runScope, error) {
+	regions, err := s.regions()
+	if err != nil {
+		return runScope{}, fmt.Errorf("error getting regions: %w", err)
+	}
+
+	sc := runScope{region: region, regions: regions, defaults: s.defaults()}
+	if region != "" {
+		if !slices.Contains(regions, region) {
+			return runScope{}, fmt.Errorf("%w: %s", ErrUnknownRegion, region)
+		}
+		sc.regions = []string{region}
+	}
+
+	if compartment != "" {
+		if sc.compartment, err = sc.defaults.Lookup(compartment); err != nil {
+			return runScope{}, err
+		}
+	}
+
+	return sc, nil
+}
+
+// requestScope resolves the region and compartment query parameters of r,
+// writing an error response and returning false if they are invalid
+func (s *server) requestScope(w http.ResponseWriter, r *http.Request) (runScope, bool) {
+	sc, err := s.scope(r.FormValue("region"), r.FormValue("compartment"))
+	switch {
+	case errors.Is(err, ErrUnknownRegion), errors.Is(err, controller.ErrUnknownCompartment),
+		errors.Is(err, controller.ErrAmbiguousCompartment):
+		s.writeError(w, http.StatusBadRequest, err)
+		return sc, false
+	case err != nil:
+		s.writeError(w, http.StatusInternalServerError, err)
+		return sc, false
+	}
+
+	return sc, true
+}
+
+// authorize requires requests to next to carry the API token as a bearer token
+func (s *server) authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || s.token == "" ||
+			subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			s.log.Warn("Unauthorized request",
+				"Path", r.URL.Path,
+				"Remote Address", r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			s.writeError(w, http.StatusUnauthorized, ErrUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// handleRun starts a run, optionally limited by region and compartment query
+// parameters [ex. POST /run?compartment=dev-team]
+func (s *server) handleRun(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.start(sc)
+	if errors.Is(err, ErrRunInProgress) {
+		s.writeError(w, http.StatusConflict, err)
+		return
+	} else if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.log.Info("Run started",
+		"Region", sc.region,
+		"Compartment", sc.compartment,
+		"Remote Address", r.RemoteAddr)
+	s.writeJSON(w, http.StatusAccepted, map[string]string{"status": "started"})
+}
+
+// handlePlan returns the actions a run would take without taking any, optionally
+// limited by region and compartment query parameters
+func (s *server) handlePlan(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.requestScope(w, r)
+	if !ok {
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, s.plan(sc))
+}
+
+// handleStatus returns whether a run is in progress and the last run summary
+func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	status := s.status
+	s.mu.Unlock()
+
+	s.writeJSON(w, http.StatusOK, status)
+}
+
+// handleHealth reports the server is able to serve requests
+func (s *server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *server) writeError(w http.ResponseWriter, code int, err error) {
+	s.writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func (s *server) writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.log.Warn("Unable to write response", "error", err)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/flynnkc/oci-frugal/src/pkg/controller"
+	"github.com/flynnkc/oci-frugal/src/pkg/id"
+)
+
+const testToken = "secret"
+
+// newTestServer returns a server over two regions and a dev compartment whose
+// runs block until release is closed and report each run's scope on started
+func newTestServer() (s *server, started chan runScope, release chan struct{}) {
+	started = make(chan runScope, 1)
+	release = make(chan struct{})
+	defaults := controller.NewCompartmentDefaults([]id.Compartment{
+		{ID: "ocid1.tenancy.oc1..root", Name: "tenancy"},
+		{ID: "ocid1.compartment.oc1..dev", Name: "dev", ParentID: "ocid1.tenancy.oc1..root"},
+	}, "Schedule", nil)
+
+	s = &server{
+		token: testToken,
+		log:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		regions: func() ([]string, error) {
+			return []string{"us-ashburn-1", "us-phoenix-1"}, nil
+		},
+		defaults: func() *controller.CompartmentDefaults { return defaults },
+		execute: func(_ context.Context, sc runScope) []*controller.RunSummary {
+			started <- sc
+			<-release
+			return nil
+		},
+		plan: func(sc runScope) []*controller.Plan { return nil },
+	}
+
+	return s, started, release
+}
+
+func request(t *testing.T, h http.Handler, method, target, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestServer_Run(t *testing.T) {
+	s, started, release := newTestServer()
+	h := s.handler()
+
+	w := request(t, h, http.MethodPost, "/run?region=us-phoenix-1&compartment=dev", testToken)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body)
+	}
+
+	sc := <-started
+	if len(sc.regions) != 1 || sc.regions[0] != "us-phoenix-1" {
+		t.Fatalf("expected run in us-phoenix-1, got %v", sc.regions)
+	}
+	if sc.compartment != "ocid1.compartment.oc1..dev" {
+		t.Fatalf("expected dev compartment OCID, got %q", sc.compartment)
+	}
+
+	if w := request(t, h, http.MethodPost, "/run", testToken); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 while running, got %d", w.Code)
+	}
+
+	var status runStatus
+	w = request(t, h, http.MethodGet, "/status", testToken)
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil || !status.Running {
+		t.Fatalf("expected running status, got %+v, %v", status, err)
+	}
+
+	close(release)
+	s.runs.Wait()
+
+	status = runStatus{}
+	w = request(t, h, http.MethodGet, "/status", testToken)
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("unable to decode status: %v", err)
+	}
+	if status.Running || status.Last == nil || status.Last.Region != "us-phoenix-1" ||
+		status.Last.End.Before(status.Last.Start) {
+		t.Fatalf("expected completed us-phoenix-1 run, got %+v", status)
+	}
+
+	// Run lock is released once the run completes
+	w = request(t, h, http.MethodPost, "/run", testToken)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202 after run completed, got %d", w.Code)
+	}
+	if sc := <-started; len(sc.regions) != 2 || sc.compartment != "" {
+		t.Fatalf("expected unscoped run, got %+v", sc)
+	}
+	s.runs.Wait()
+}
+
+func TestServer_BadRequest(t *testing.T) {
+	s, _, _ := newTestServer()
+	h := s.handler()
+
+	cases := []struct {
+		method string
+		target string
+	}{
+		{http.MethodPost, "/run?region=eu-frankfurt-1"},
+		{http.MethodPost, "/run?compartment=missing"},
+		{http.MethodGet, "/plan?region=eu-frankfurt-1"},
+	}
+	for _, c := range cases {
+		if w := request(t, h, c.method, c.target, testToken); w.Code != http.StatusBadRequest {
+			t.Fatalf("%s %s: expected 400, got %d", c.method, c.target, w.Code)
+		}
+	}
+}
+
+func TestServer_Unauthorized(t *testing.T) {
+	s, _, _ := newTestServer()
+	h := s.handler()
+
+	cases := []struct {
+		method string
+		target string
+		token  string
+	}{
+		{http.MethodPost, "/run", ""},
+		{http.MethodPost, "/run", "wrong"},
+		{http.MethodGet, "/plan", ""},
+		{http.MethodGet, "/status", ""},
+	}
+	for _, c := range cases {
+		if w := request(t, h, c.method, c.target, c.token); w.Code != http.StatusUnauthorized {
+			t.Fatalf("%s %s: expected 401, got %d", c.method, c.target, w.Code)
+		}
+	}
+
+	// Health checks do not require the token
+	if w := request(t, h, http.MethodGet, "/healthz", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for health check, got %d", w.Code)
+	}
+
+	// A server without a token refuses every request
+	s.token = ""
+	if w := request(t, h, http.MethodGet, "/status", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without configured token, got %d", w.Code)
+	}
+}
+
+func TestServer_Schedule(t *testing.T) {
+	s, started, release := newTestServer()
+	close(release)
+
+	go s.schedule(t.Context(), time.Hour)
+
+	if sc := <-started; len(sc.regions) != 2 {
+		t.Fatalf("expected scheduled run in all regions, got %v", sc.regions)
+	}
+}